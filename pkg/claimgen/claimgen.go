@@ -3,6 +3,9 @@ package claimgen
 import (
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
 
 	rewardsCoordinator "github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IRewardsCoordinator"
 
@@ -10,6 +13,7 @@ import (
 	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/utils"
 
 	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 
 	"github.com/wealdtech/go-merkletree/v2"
 )
@@ -17,8 +21,19 @@ import (
 var ErrEarnerIndexNotFound = errors.New("earner index not found")
 var ErrTokenIndexNotFound = errors.New("token not found")
 var ErrAmountNotFound = errors.New("amount not found")
+var ErrIndexOverflow = errors.New("index does not fit in uint32")
+var ErrAmountMismatch = errors.New("reporting view amount does not match the merklized distribution")
 
-// GetProofForEarner Helper function for getting the proof for the specified earner and tokens
+// ProofSource is what proof generation needs from a merklized distribution.
+// It is implemented by Distribution, including compact distributions, and MerklizedDistribution.
+type ProofSource interface {
+	Get(address, token gethcommon.Address) (*big.Int, bool)
+	GetAccountIndex(address gethcommon.Address) (uint64, bool)
+	GetTokenIndex(address, token gethcommon.Address) (uint64, bool)
+	GetTokenTree(address gethcommon.Address) (*merkletree.MerkleTree, error)
+}
+
+// GetProofForEarner Helper function for getting the proof for the specified earner and tokens, see GetProofFromSource
 func GetProofForEarner(
 	distribution *distribution.Distribution,
 	rootIndex uint32,
@@ -27,29 +42,105 @@ func GetProofForEarner(
 	earner gethcommon.Address,
 	tokens []gethcommon.Address,
 ) (*rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim, error) {
-	earnerIndex, found := distribution.GetAccountIndex(earner)
+	return GetProofFromSource(distribution, rootIndex, accountTree, tokenTrees, earner, tokens)
+}
+
+// GetProofForMerklizedEarner is GetProofForEarner for a frozen distribution, and is safe for concurrent use
+func GetProofForMerklizedEarner(
+	merklized *distribution.MerklizedDistribution,
+	rootIndex uint32,
+	earner gethcommon.Address,
+	tokens []gethcommon.Address,
+) (*rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim, error) {
+	return GetProofFromSource(merklized, rootIndex, merklized.AccountTree(), nil, earner, tokens)
+}
+
+// GetProofsForReportingView generates a proof for every earner in a view created by Distribution.Filter,
+// covering the earner's tokens in the view. The proofs are against the full merklized distribution that the
+// view was filtered from, so they verify against the published root rather than the view's own root.
+// Claims are returned in the view's tree order.
+func GetProofsForReportingView(
+	source ProofSource,
+	rootIndex uint32,
+	accountTree *merkletree.MerkleTree,
+	tokenTrees map[gethcommon.Address]*merkletree.MerkleTree,
+	view *distribution.Distribution,
+) ([]*rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim, error) {
+	claims := make([]*rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim, 0, view.EarnerCount())
+	var err error
+	view.ForEachEarner(func(earner gethcommon.Address) bool {
+		tokens := make([]gethcommon.Address, 0, view.TokenCount(earner))
+		view.ForEachToken(earner, func(token gethcommon.Address, amount *big.Int) bool {
+			tokens = append(tokens, token)
+			if sourceAmount, found := source.Get(earner, token); found && sourceAmount.Cmp(amount) != 0 {
+				err = fmt.Errorf("%w for token %s and earner %s - view: %s, merklized: %s",
+					ErrAmountMismatch, token.Hex(), earner.Hex(), amount, sourceAmount)
+			}
+			return err == nil
+		})
+		if err != nil {
+			return false
+		}
+
+		var claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim
+		claim, err = GetProofFromSource(source, rootIndex, accountTree, tokenTrees, earner, tokens)
+		claims = append(claims, claim)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// GetProofFromSource gets the proof for the specified earner and tokens from any ProofSource.
+// If the earner's token tree is not in tokenTrees it is rebuilt from the source, so tokenTrees may be nil.
+func GetProofFromSource(
+	source ProofSource,
+	rootIndex uint32,
+	accountTree *merkletree.MerkleTree,
+	tokenTrees map[gethcommon.Address]*merkletree.MerkleTree,
+	earner gethcommon.Address,
+	tokens []gethcommon.Address,
+) (*rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim, error) {
+	earnerIndex, found := source.GetAccountIndex(earner)
 	if !found {
 		return nil, fmt.Errorf("%w for earner %s", ErrEarnerIndexNotFound, earner.Hex())
 	}
+	if earnerIndex > math.MaxUint32 {
+		return nil, fmt.Errorf("%w - earner index %d for earner %s", ErrIndexOverflow, earnerIndex, earner.Hex())
+	}
+
+	tokenTree, found := tokenTrees[earner]
+	if !found {
+		var err error
+		tokenTree, err = source.GetTokenTree(earner)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// get the token proofs
 	tokenIndices := make([]uint32, 0)
 	tokenProofsBytes := make([][]byte, 0)
 	tokenLeaves := make([]rewardsCoordinator.IRewardsCoordinatorTypesTokenTreeMerkleLeaf, 0)
 	for _, token := range tokens {
-		tokenIndex, found := distribution.GetTokenIndex(earner, token)
+		tokenIndex, found := source.GetTokenIndex(earner, token)
 		if !found {
 			return nil, fmt.Errorf("%w for token %s and earner %s", ErrTokenIndexNotFound, token.Hex(), earner.Hex())
 		}
+		if tokenIndex > math.MaxUint32 {
+			return nil, fmt.Errorf("%w - token index %d for token %s and earner %s", ErrIndexOverflow, tokenIndex, token.Hex(), earner.Hex())
+		}
 		tokenIndices = append(tokenIndices, uint32(tokenIndex))
 
-		tokenProof, err := tokenTrees[earner].GenerateProofWithIndex(tokenIndex, 0)
+		tokenProof, err := tokenTree.GenerateProofWithIndex(tokenIndex, 0)
 		if err != nil {
 			return nil, err
 		}
 		tokenProofsBytes = append(tokenProofsBytes, flattenHashes(tokenProof.Hashes))
 
-		amount, found := distribution.Get(earner, token)
+		amount, found := source.Get(earner, token)
 		if !found {
 			// this should never happen due to the token index check above
 			return nil, fmt.Errorf("%w for token %s and earner %s", ErrAmountNotFound, token.Hex(), earner.Hex())
@@ -61,7 +152,7 @@ func GetProofForEarner(
 	}
 
 	var earnerRoot [32]byte
-	copy(earnerRoot[:], tokenTrees[earner].Root())
+	copy(earnerRoot[:], tokenTree.Root())
 
 	// get the account proof
 	earnerTreeProof, err := accountTree.GenerateProofWithIndex(earnerIndex, 0)
@@ -85,6 +176,78 @@ func GetProofForEarner(
 	}, nil
 }
 
+// GetProofFromTreeDump builds the proof for the specified earner and tokens from the layers of a tree dump alone,
+// the way a third party without the distribution would. Use distribution.ReadTreeDump to check the dump first.
+func GetProofFromTreeDump(
+	dump *distribution.TreeDump,
+	rootIndex uint32,
+	earner gethcommon.Address,
+	tokens []gethcommon.Address,
+) (*rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim, error) {
+	earnerIndex := sort.Search(len(dump.Earners), func(i int) bool {
+		return dump.Earners[i].Earner.Cmp(earner) >= 0
+	})
+	if earnerIndex == len(dump.Earners) || dump.Earners[earnerIndex].Earner != earner {
+		return nil, fmt.Errorf("%w for earner %s", ErrEarnerIndexNotFound, earner.Hex())
+	}
+	if uint64(earnerIndex) > math.MaxUint32 {
+		return nil, fmt.Errorf("%w - earner index %d for earner %s", ErrIndexOverflow, earnerIndex, earner.Hex())
+	}
+	earnerDump := dump.Earners[earnerIndex]
+
+	tokenIndices := make([]uint32, 0)
+	tokenProofsBytes := make([][]byte, 0)
+	tokenLeaves := make([]rewardsCoordinator.IRewardsCoordinatorTypesTokenTreeMerkleLeaf, 0)
+	for _, token := range tokens {
+		tokenIndex := sort.Search(len(earnerDump.Tokens), func(i int) bool {
+			return earnerDump.Tokens[i].Token.Cmp(token) >= 0
+		})
+		if tokenIndex == len(earnerDump.Tokens) || earnerDump.Tokens[tokenIndex].Token != token {
+			return nil, fmt.Errorf("%w for token %s and earner %s", ErrTokenIndexNotFound, token.Hex(), earner.Hex())
+		}
+		if uint64(tokenIndex) > math.MaxUint32 {
+			return nil, fmt.Errorf("%w - token index %d for token %s and earner %s", ErrIndexOverflow, tokenIndex, token.Hex(), earner.Hex())
+		}
+		amount, ok := new(big.Int).SetString(earnerDump.Tokens[tokenIndex].CumulativeAmount, 10)
+		if !ok {
+			return nil, fmt.Errorf("%w for token %s and earner %s", ErrAmountNotFound, token.Hex(), earner.Hex())
+		}
+
+		tokenIndices = append(tokenIndices, uint32(tokenIndex))
+		tokenProofsBytes = append(tokenProofsBytes, layersProof(earnerDump.TokenTreeLayers, tokenIndex))
+		tokenLeaves = append(tokenLeaves, rewardsCoordinator.IRewardsCoordinatorTypesTokenTreeMerkleLeaf{
+			Token:              token,
+			CumulativeEarnings: amount,
+		})
+	}
+
+	var earnerRoot [32]byte
+	copy(earnerRoot[:], earnerDump.TokenRoot)
+
+	return &rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim{
+		RootIndex:       rootIndex,
+		EarnerIndex:     uint32(earnerIndex),
+		EarnerTreeProof: layersProof(dump.AccountTreeLayers, earnerIndex),
+		EarnerLeaf: rewardsCoordinator.IRewardsCoordinatorTypesEarnerTreeMerkleLeaf{
+			Earner:          earner,
+			EarnerTokenRoot: earnerRoot,
+		},
+		TokenIndices:    tokenIndices,
+		TokenTreeProofs: tokenProofsBytes,
+		TokenLeaves:     tokenLeaves,
+	}, nil
+}
+
+// layersProof collects the sibling of the leaf's ancestor on every layer below the root
+func layersProof(layers [][]hexutil.Bytes, index int) []byte {
+	proof := make([]byte, 0)
+	for _, layer := range layers[:len(layers)-1] {
+		proof = append(proof, layer[index^1]...)
+		index /= 2
+	}
+	return proof
+}
+
 func flattenHashes(hashes [][]byte) []byte {
 	result := make([]byte, 0)
 	for i := 0; i < len(hashes); i++ {
@@ -164,7 +327,8 @@ func (c *Claimgen) GenerateClaimProofForEarner(
 	*rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim,
 	error,
 ) {
-	accountTree, tokenTrees, err := c.distribution.Merklize()
+	// only the earner's token tree is needed, so don't keep the rest
+	accountTree, err := c.distribution.MerklizeAccounts()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -173,7 +337,7 @@ func (c *Claimgen) GenerateClaimProofForEarner(
 		c.distribution,
 		rootIndex,
 		accountTree,
-		tokenTrees,
+		nil,
 		earner,
 		tokens,
 	)
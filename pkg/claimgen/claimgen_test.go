@@ -1,11 +1,17 @@
 package claimgen
 
 import (
+	"bytes"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	rewardsCoordinator "github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IRewardsCoordinator"
 	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
 	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func TestNewClaimgen(t *testing.T) {
@@ -65,3 +71,301 @@ func TestFormatProofForSolidity(t *testing.T) {
 
 	assert.NotNil(t, claimStrings)
 }
+
+func getTestDistribution() *distribution.Distribution {
+	d := distribution.NewDistribution()
+	for i := 0; i < len(tests.TestAddresses); i++ {
+		for j := 0; j < len(tests.TestTokens)-i; j++ {
+			d.Set(tests.TestAddresses[i], tests.TestTokens[j], big.NewInt(int64(j+i+1)))
+		}
+	}
+	return d
+}
+
+func TestVerifyClaim(t *testing.T) {
+	distro := getTestDistribution()
+
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+
+	for i, earner := range tests.TestAddresses {
+		claim, err := GetProofForEarner(distro, 0, accounts, tokens, earner, tests.TestTokens[:len(tests.TestTokens)-i])
+		assert.Nil(t, err)
+
+		assert.Nil(t, VerifyClaim(accounts.Root(), claim))
+	}
+}
+
+func TestGetProofForEarnerWithoutTokenTrees(t *testing.T) {
+	distro := getTestDistribution()
+
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+	lightAccounts, err := distro.MerklizeAccounts()
+	assert.Nil(t, err)
+
+	earner := tests.TestAddresses[2]
+	claim, err := GetProofForEarner(distro, 0, accounts, tokens, earner, tests.TestTokens[:2])
+	assert.Nil(t, err)
+	lightClaim, err := GetProofForEarner(distro, 0, lightAccounts, nil, earner, tests.TestTokens[:2])
+	assert.Nil(t, err)
+
+	assert.Equal(t, claim, lightClaim)
+	assert.Nil(t, VerifyClaim(lightAccounts.Root(), lightClaim))
+}
+
+func TestGenerateClaimProofForEarner(t *testing.T) {
+	cg := NewClaimgen(getTestDistribution())
+
+	accounts, claim, err := cg.GenerateClaimProofForEarner(tests.TestAddresses[1], tests.TestTokens[:4], 0)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyClaim(accounts.Root(), claim))
+}
+
+func TestGetProofForEarnerFromSnapshot(t *testing.T) {
+	distro := getTestDistribution()
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, distribution.WriteSnapshot(&buf, distro, accounts, time.Unix(1714780800, 0)))
+	snapshot, err := distribution.ReadSnapshot(&buf)
+	assert.Nil(t, err)
+
+	earner := tests.TestAddresses[3]
+	claim, err := GetProofForEarner(distro, 0, accounts, tokens, earner, tests.TestTokens[:2])
+	assert.Nil(t, err)
+	snapshotClaim, err := GetProofForEarner(snapshot.Distribution, 0, snapshot.AccountTree, nil, earner, tests.TestTokens[:2])
+	assert.Nil(t, err)
+
+	assert.Equal(t, claim, snapshotClaim)
+	assert.Nil(t, VerifyClaim(snapshot.Root, snapshotClaim))
+}
+
+func TestGetProofForMerklizedEarnerConcurrently(t *testing.T) {
+	distro := getTestDistribution()
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+
+	merklized, err := distro.Freeze()
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i, earner := range tests.TestAddresses {
+		earner := earner
+		earnerTokens := tests.TestTokens[:len(tests.TestTokens)-i]
+		expected, err := GetProofForEarner(distro, 0, accounts, tokens, earner, earnerTokens)
+		assert.Nil(t, err)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				claim, err := GetProofForMerklizedEarner(merklized, 0, earner, earnerTokens)
+				assert.Nil(t, err)
+				assert.Equal(t, expected, claim)
+				assert.Nil(t, VerifyClaim(merklized.Root(), claim))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestVerifyClaimSingleLeaf(t *testing.T) {
+	distro, err := distribution.NewDistributionWithData(tests.TestJsonDistribution)
+	assert.Nil(t, err)
+
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+
+	earner := common.HexToAddress("0x0D6bA28b9919CfCDb6b233469Cc5Ce30b979e08E")
+	token := common.HexToAddress("0x1006dd1B8C3D0eF53489beD27577C75299F71473")
+	claim, err := GetProofForEarner(distro, 0, accounts, tokens, earner, []common.Address{token})
+	assert.Nil(t, err)
+
+	assert.Nil(t, VerifyClaim(accounts.Root(), claim))
+}
+
+func TestVerifyClaimInvalid(t *testing.T) {
+	distro := getTestDistribution()
+
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+
+	earner := tests.TestAddresses[1]
+	newClaim := func() *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim {
+		claim, err := GetProofForEarner(distro, 0, accounts, tokens, earner, tests.TestTokens[:3])
+		assert.Nil(t, err)
+		return claim
+	}
+
+	t.Run("wrong root", func(t *testing.T) {
+		err := VerifyClaim(tokens[earner].Root(), newClaim())
+		assert.ErrorIs(t, err, ErrInvalidEarnerProof)
+	})
+
+	t.Run("tampered earner proof", func(t *testing.T) {
+		claim := newClaim()
+		claim.EarnerTreeProof[0] ^= 0xff
+		assert.ErrorIs(t, VerifyClaim(accounts.Root(), claim), ErrInvalidEarnerProof)
+	})
+
+	t.Run("earner index out of range", func(t *testing.T) {
+		claim := newClaim()
+		claim.EarnerIndex = 8
+		assert.ErrorIs(t, VerifyClaim(accounts.Root(), claim), ErrLeafIndexOutOfRange)
+	})
+
+	t.Run("truncated earner proof", func(t *testing.T) {
+		claim := newClaim()
+		claim.EarnerTreeProof = claim.EarnerTreeProof[:len(claim.EarnerTreeProof)-1]
+		assert.ErrorIs(t, VerifyClaim(accounts.Root(), claim), ErrInvalidProofLength)
+	})
+
+	t.Run("array length mismatch", func(t *testing.T) {
+		claim := newClaim()
+		claim.TokenIndices = claim.TokenIndices[:2]
+		assert.ErrorIs(t, VerifyClaim(accounts.Root(), claim), ErrInputArrayLengthMismatch)
+	})
+
+	t.Run("tampered token amount", func(t *testing.T) {
+		claim := newClaim()
+		claim.TokenLeaves[2].CumulativeEarnings = big.NewInt(1000)
+
+		err := VerifyClaim(accounts.Root(), claim)
+		assert.ErrorIs(t, err, ErrTokenRootMismatch)
+
+		var tokenErr *TokenProofError
+		assert.ErrorAs(t, err, &tokenErr)
+		assert.Equal(t, 2, tokenErr.Index)
+	})
+
+	t.Run("wrong token index", func(t *testing.T) {
+		claim := newClaim()
+		claim.TokenIndices[1] = 0
+
+		var tokenErr *TokenProofError
+		assert.ErrorAs(t, VerifyClaim(accounts.Root(), claim), &tokenErr)
+		assert.Equal(t, 1, tokenErr.Index)
+	})
+}
+
+func TestGetProofFromCompactDistribution(t *testing.T) {
+	distro := getTestDistribution()
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+
+	compact, err := distro.Compact()
+	assert.Nil(t, err)
+	compactAccounts, err := compact.MerklizeAccounts()
+	assert.Nil(t, err)
+
+	for i, earner := range tests.TestAddresses {
+		earnerTokens := tests.TestTokens[:len(tests.TestTokens)-i]
+		claim, err := GetProofForEarner(distro, 0, accounts, tokens, earner, earnerTokens)
+		assert.Nil(t, err)
+		compactClaim, err := GetProofForEarner(compact, 0, compactAccounts, nil, earner, earnerTokens)
+		assert.Nil(t, err)
+
+		assert.Equal(t, claim, compactClaim)
+		assert.Nil(t, VerifyClaim(compactAccounts.Root(), compactClaim))
+	}
+}
+
+func TestGetProofsForReportingView(t *testing.T) {
+	distro := getTestDistribution()
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+
+	view := distro.Filter(distribution.Filter{
+		Earners: []common.Address{tests.TestAddresses[1], tests.TestAddresses[3]},
+		Tokens:  tests.TestTokens[:2],
+	})
+
+	claims, err := GetProofsForReportingView(distro, 0, accounts, tokens, view)
+	assert.Nil(t, err)
+	assert.Len(t, claims, 2)
+	for i, claim := range claims {
+		assert.Equal(t, tests.TestAddresses[1+2*i], claim.EarnerLeaf.Earner)
+		assert.Len(t, claim.TokenLeaves, 2)
+		assert.Nil(t, VerifyClaim(accounts.Root(), claim))
+	}
+
+	// amounts changed in the view no longer match the merklized distribution
+	assert.Nil(t, view.Update(tests.TestAddresses[3], tests.TestTokens[1], big.NewInt(1000)))
+	_, err = GetProofsForReportingView(distro, 0, accounts, tokens, view)
+	assert.ErrorIs(t, err, ErrAmountMismatch)
+}
+
+func TestGetProofFromTreeDump(t *testing.T) {
+	distro := getTestDistribution()
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+	merklized, err := distro.Freeze()
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, distribution.WriteTreeDump(&buf, merklized))
+	dump, _, err := distribution.ReadTreeDump(&buf)
+	assert.Nil(t, err)
+
+	for i, earner := range tests.TestAddresses {
+		earnerTokens := tests.TestTokens[:len(tests.TestTokens)-i]
+		expected, err := GetProofForEarner(distro, 0, accounts, tokens, earner, earnerTokens)
+		assert.Nil(t, err)
+
+		claim, err := GetProofFromTreeDump(dump, 0, earner, earnerTokens)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, claim)
+		assert.Nil(t, VerifyClaim(dump.Root, claim))
+	}
+
+	_, err = GetProofFromTreeDump(dump, 0, tests.TestTokens[0], nil)
+	assert.ErrorIs(t, err, ErrEarnerIndexNotFound)
+	_, err = GetProofFromTreeDump(dump, 0, tests.TestAddresses[0], []common.Address{tests.TestAddresses[0]})
+	assert.ErrorIs(t, err, ErrTokenIndexNotFound)
+}
+
+// testLeafScheme is LeafSchemeV1 with different salts
+type testLeafScheme struct{}
+
+func (testLeafScheme) Version() uint16 {
+	return 99
+}
+
+func (testLeafScheme) EncodeAccountLeaf(earner common.Address, tokenRoot []byte) ([]byte, error) {
+	leaf, err := distribution.LeafSchemeV1.EncodeAccountLeaf(earner, tokenRoot)
+	if err != nil {
+		return nil, err
+	}
+	leaf[0] = 0xe0
+	return leaf, nil
+}
+
+func (testLeafScheme) DecodeAccountLeaf(leaf []byte) (common.Address, []byte, error) {
+	return common.BytesToAddress(leaf[1:21]), leaf[21:], nil
+}
+
+func (testLeafScheme) EncodeTokenLeaf(token common.Address, amount *big.Int) ([]byte, error) {
+	leaf, err := distribution.LeafSchemeV1.EncodeTokenLeaf(token, amount)
+	if err != nil {
+		return nil, err
+	}
+	leaf[0] = 0xe1
+	return leaf, nil
+}
+
+func TestVerifyClaimWithLeafScheme(t *testing.T) {
+	distro := distribution.NewDistributionWithLeafScheme(testLeafScheme{})
+	getTestDistribution().ForEachLeaf(func(earner, token common.Address, amount *big.Int) bool {
+		assert.Nil(t, distro.Set(earner, token, amount))
+		return true
+	})
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+
+	claim, err := GetProofForEarner(distro, 0, accounts, tokens, tests.TestAddresses[1], tests.TestTokens[:3])
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyClaimWithLeafScheme(accounts.Root(), claim, testLeafScheme{}))
+	assert.ErrorIs(t, VerifyClaim(accounts.Root(), claim), ErrInvalidEarnerProof)
+}
@@ -0,0 +1,221 @@
+package claimgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	rewardsCoordinator "github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IRewardsCoordinator"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/utils"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// FixturesVersion is the version of the fixture layout written by WriteFixtures
+const FixturesVersion = 1
+
+var ErrFixtureMismatch = errors.New("fixture case does not verify as expected")
+
+// NamedDistribution is a distribution to generate fixtures for
+type NamedDistribution struct {
+	Name         string
+	Distribution *distribution.Distribution
+}
+
+// Fixtures are test vectors shared with the RewardsCoordinator's Solidity tests
+type Fixtures struct {
+	Cases         []FixtureCase         `json:"cases"`
+	Distributions []FixtureDistribution `json:"distributions"`
+	Version       uint32                `json:"version"`
+}
+
+// FixtureDistribution is a distribution and its root, with every leaf in tree order.
+// LeafScheme is the version of the leaf scheme the trees were built with.
+type FixtureDistribution struct {
+	LeafScheme uint16        `json:"leafScheme"`
+	Leaves     []FixtureLeaf `json:"leaves"`
+	Name       string        `json:"name"`
+	Root       string        `json:"root"`
+}
+
+// FixtureLeaf is an earner, token and cumulative amount
+type FixtureLeaf struct {
+	CumulativeAmount string             `json:"cumulativeAmount"`
+	Earner           gethcommon.Address `json:"earner"`
+	Token            gethcommon.Address `json:"token"`
+}
+
+// FixtureCase is a claim against a distribution's root, and whether it is expected to verify
+type FixtureCase struct {
+	Claim        *IRewardsCoordinatorRewardsMerkleClaimStrings `json:"claim"`
+	Distribution string                                        `json:"distribution"`
+	Name         string                                        `json:"name"`
+	Valid        bool                                          `json:"valid"`
+}
+
+// GenerateFixtures generates a valid claim of every token for every earner of each distribution,
+// and claims tampered in each way the RewardsCoordinator must reject for the first earner.
+// Every case is checked with VerifyClaimWithLeafScheme using the distribution's leaf scheme,
+// and the output only depends on the distributions.
+func GenerateFixtures(distributions []NamedDistribution) (*Fixtures, error) {
+	fixtures := &Fixtures{
+		Cases:         make([]FixtureCase, 0),
+		Distributions: make([]FixtureDistribution, 0, len(distributions)),
+		Version:       FixturesVersion,
+	}
+
+	for _, named := range distributions {
+		merklized, err := named.Distribution.Freeze()
+		if err != nil {
+			return nil, fmt.Errorf("failed to merklize %s: %w", named.Name, err)
+		}
+		root := merklized.Root()
+		scheme := merklized.LeafScheme()
+
+		fixtureDistribution := FixtureDistribution{
+			LeafScheme: scheme.Version(),
+			Leaves:     make([]FixtureLeaf, 0),
+			Name:       named.Name,
+			Root:       utils.ConvertBytesToString(root),
+		}
+		merklized.ForEachLeaf(func(earner, token gethcommon.Address, amount *big.Int) bool {
+			fixtureDistribution.Leaves = append(fixtureDistribution.Leaves, FixtureLeaf{
+				CumulativeAmount: amount.String(),
+				Earner:           earner,
+				Token:            token,
+			})
+			return true
+		})
+		fixtures.Distributions = append(fixtures.Distributions, fixtureDistribution)
+
+		addCase := func(name string, claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim, valid bool) error {
+			if verified := VerifyClaimWithLeafScheme(root, claim, scheme) == nil; verified != valid {
+				return fmt.Errorf("%w - distribution: %s, case: %s, expected valid: %t", ErrFixtureMismatch, named.Name, name, valid)
+			}
+			fixtures.Cases = append(fixtures.Cases, FixtureCase{
+				Claim:        FormatProofForSolidity(root, claim),
+				Distribution: named.Name,
+				Name:         name,
+				Valid:        valid,
+			})
+			return nil
+		}
+
+		earners := make([]gethcommon.Address, 0, merklized.EarnerCount())
+		merklized.ForEachEarner(func(earner gethcommon.Address) bool {
+			earners = append(earners, earner)
+			return true
+		})
+		for i, earner := range earners {
+			tokens := make([]gethcommon.Address, 0, merklized.TokenCount(earner))
+			merklized.ForEachToken(earner, func(token gethcommon.Address, _ *big.Int) bool {
+				tokens = append(tokens, token)
+				return true
+			})
+			claim, err := GetProofForMerklizedEarner(merklized, 0, earner, tokens)
+			if err != nil {
+				return nil, err
+			}
+			if err := addCase(fmt.Sprintf("valid_earner_%d", i), claim, true); err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				continue
+			}
+			for _, tamper := range claimTampers {
+				tampered := copyClaim(claim)
+				if !tamper.apply(tampered) {
+					continue
+				}
+				if err := addCase(tamper.name, tampered, false); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return fixtures, nil
+}
+
+// WriteFixtures writes the fixtures as indented JSON, for reading with forge's vm.readFile and vm.parseJson
+func WriteFixtures(w io.Writer, fixtures *Fixtures) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(fixtures)
+}
+
+// claimTampers are the ways a claim is broken for the invalid cases. apply returns false if it does not
+// apply to the claim, such as flipping a byte of an empty proof.
+var claimTampers = []struct {
+	name  string
+	apply func(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) bool
+}{
+	{"tampered_amount", func(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) bool {
+		leaf := &claim.TokenLeaves[0]
+		leaf.CumulativeEarnings = new(big.Int).Add(leaf.CumulativeEarnings, big.NewInt(1))
+		return true
+	}},
+	{"tampered_token", func(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) bool {
+		claim.TokenLeaves[0].Token[19] ^= 1
+		return true
+	}},
+	{"tampered_earner", func(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) bool {
+		claim.EarnerLeaf.Earner[19] ^= 1
+		return true
+	}},
+	{"tampered_earner_token_root", func(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) bool {
+		claim.EarnerLeaf.EarnerTokenRoot[0] ^= 1
+		return true
+	}},
+	{"tampered_earner_proof", func(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) bool {
+		if len(claim.EarnerTreeProof) == 0 {
+			return false
+		}
+		claim.EarnerTreeProof[0] ^= 1
+		return true
+	}},
+	{"tampered_token_proof", func(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) bool {
+		if len(claim.TokenTreeProofs[0]) == 0 {
+			return false
+		}
+		claim.TokenTreeProofs[0][0] ^= 1
+		return true
+	}},
+	{"wrong_earner_index", func(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) bool {
+		claim.EarnerIndex ^= 1
+		return true
+	}},
+	{"wrong_token_index", func(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) bool {
+		claim.TokenIndices[0] ^= 1
+		return true
+	}},
+	{"truncated_earner_proof", func(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) bool {
+		if len(claim.EarnerTreeProof) == 0 {
+			return false
+		}
+		claim.EarnerTreeProof = claim.EarnerTreeProof[:len(claim.EarnerTreeProof)-32]
+		return true
+	}},
+}
+
+// copyClaim deep copies a claim so that tampering with it leaves the original intact
+func copyClaim(claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim {
+	copied := *claim
+	copied.EarnerTreeProof = append([]byte{}, claim.EarnerTreeProof...)
+	copied.TokenIndices = append([]uint32{}, claim.TokenIndices...)
+	copied.TokenTreeProofs = make([][]byte, 0, len(claim.TokenTreeProofs))
+	for _, proof := range claim.TokenTreeProofs {
+		copied.TokenTreeProofs = append(copied.TokenTreeProofs, append([]byte{}, proof...))
+	}
+	copied.TokenLeaves = make([]rewardsCoordinator.IRewardsCoordinatorTypesTokenTreeMerkleLeaf, 0, len(claim.TokenLeaves))
+	for _, leaf := range claim.TokenLeaves {
+		copied.TokenLeaves = append(copied.TokenLeaves, rewardsCoordinator.IRewardsCoordinatorTypesTokenTreeMerkleLeaf{
+			Token:              leaf.Token,
+			CumulativeEarnings: new(big.Int).Set(leaf.CumulativeEarnings),
+		})
+	}
+	return &copied
+}
@@ -0,0 +1,110 @@
+package claimgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateFixtures = flag.Bool("update-fixtures", false, "regenerate testdata/solidity_fixtures.json")
+
+func getFixtureDistributions(t *testing.T) []NamedDistribution {
+	singleLeaf, err := distribution.NewDistributionWithData(tests.TestJsonDistribution)
+	assert.Nil(t, err)
+
+	// the largest and smallest amounts a token leaf can hold
+	boundaryAmounts := distribution.NewDistribution()
+	maxAmount := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	assert.Nil(t, boundaryAmounts.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(0)))
+	assert.Nil(t, boundaryAmounts.Set(tests.TestAddresses[0], tests.TestTokens[1], maxAmount))
+	for i, amount := range tests.TestAmountsString {
+		parsed, _ := new(big.Int).SetString(amount, 10)
+		assert.Nil(t, boundaryAmounts.Set(tests.TestAddresses[1], tests.TestTokens[i], parsed))
+	}
+
+	return []NamedDistribution{
+		{Name: "single_leaf", Distribution: singleLeaf},
+		{Name: "uneven_tokens", Distribution: getTestDistribution()},
+		{Name: "boundary_amounts", Distribution: boundaryAmounts},
+	}
+}
+
+func TestGenerateFixtures(t *testing.T) {
+	fixtures, err := GenerateFixtures(getFixtureDistributions(t))
+	assert.Nil(t, err)
+
+	assert.Len(t, fixtures.Distributions, 3)
+	valid, invalid := 0, 0
+	for _, fixtureCase := range fixtures.Cases {
+		if fixtureCase.Valid {
+			valid++
+		} else {
+			invalid++
+		}
+	}
+	// one valid case per earner
+	assert.Equal(t, 1+len(tests.TestAddresses)+2, valid)
+	// the single leaf has no proofs to tamper with
+	assert.Equal(t, 6+9+9, invalid)
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteFixtures(&buf, fixtures))
+
+	// the output is deterministic
+	again, err := GenerateFixtures(getFixtureDistributions(t))
+	assert.Nil(t, err)
+	var againBuf bytes.Buffer
+	assert.Nil(t, WriteFixtures(&againBuf, again))
+	assert.Equal(t, buf.String(), againBuf.String())
+
+	path := filepath.Join("testdata", "solidity_fixtures.json")
+	if *updateFixtures {
+		assert.Nil(t, os.WriteFile(path, buf.Bytes(), 0o644))
+	}
+	golden, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, string(golden), buf.String(), "run go test ./pkg/claimgen -update-fixtures to regenerate")
+
+	var decoded Fixtures
+	assert.Nil(t, json.Unmarshal(golden, &decoded))
+	assert.Equal(t, uint32(FixturesVersion), decoded.Version)
+	for _, fixtureDistribution := range decoded.Distributions {
+		assert.Equal(t, distribution.LeafSchemeV1.Version(), fixtureDistribution.LeafScheme)
+	}
+}
+
+func TestGenerateFixturesWithLeafScheme(t *testing.T) {
+	distro := distribution.NewDistributionWithLeafScheme(testLeafScheme{})
+	getTestDistribution().ForEachLeaf(func(earner, token common.Address, amount *big.Int) bool {
+		assert.Nil(t, distro.Set(earner, token, amount))
+		return true
+	})
+
+	fixtures, err := GenerateFixtures([]NamedDistribution{{Name: "test_scheme", Distribution: distro}})
+	assert.Nil(t, err)
+	assert.Equal(t, testLeafScheme{}.Version(), fixtures.Distributions[0].LeafScheme)
+}
+
+func TestClaimTampersLeaveClaimIntact(t *testing.T) {
+	distro := getTestDistribution()
+	accounts, tokens, err := distro.Merklize()
+	assert.Nil(t, err)
+	claim, err := GetProofForEarner(distro, 0, accounts, tokens, tests.TestAddresses[0], tests.TestTokens)
+	assert.Nil(t, err)
+
+	for _, tamper := range claimTampers {
+		tampered := copyClaim(claim)
+		assert.True(t, tamper.apply(tampered), tamper.name)
+		assert.NotNil(t, VerifyClaim(accounts.Root(), tampered), tamper.name)
+	}
+	assert.Nil(t, VerifyClaim(accounts.Root(), claim))
+}
@@ -0,0 +1,104 @@
+package claimgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	rewardsCoordinator "github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IRewardsCoordinator"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var ErrInputArrayLengthMismatch = errors.New("token indices, proofs and leaves must have the same length")
+var ErrInvalidProofLength = errors.New("proof length must be a multiple of 32")
+var ErrLeafIndexOutOfRange = errors.New("leaf index out of range for proof length")
+var ErrInvalidEarnerProof = errors.New("earner proof does not match root")
+var ErrTokenRootMismatch = errors.New("token proof does not match earner token root")
+
+// TokenProofError is returned by VerifyClaim when the token proof at Index fails verification
+type TokenProofError struct {
+	Index int
+	Err   error
+}
+
+func (e *TokenProofError) Error() string {
+	return fmt.Sprintf("invalid token proof %d: %v", e.Index, e.Err)
+}
+
+func (e *TokenProofError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyClaim checks a claim against the given root the same way RewardsCoordinator.checkClaim does,
+// minus the on-chain checks on the root itself (disabled, activation delay, root index).
+func VerifyClaim(root []byte, claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim) error {
+	return VerifyClaimWithLeafScheme(root, claim, distribution.LeafSchemeV1)
+}
+
+// VerifyClaimWithLeafScheme is VerifyClaim for a distribution merklized with the given leaf scheme
+func VerifyClaimWithLeafScheme(
+	root []byte,
+	claim *rewardsCoordinator.IRewardsCoordinatorTypesRewardsMerkleClaim,
+	scheme distribution.LeafScheme,
+) error {
+	if len(claim.TokenIndices) != len(claim.TokenTreeProofs) || len(claim.TokenTreeProofs) != len(claim.TokenLeaves) {
+		return fmt.Errorf("%w - indices: %d, proofs: %d, leaves: %d",
+			ErrInputArrayLengthMismatch, len(claim.TokenIndices), len(claim.TokenTreeProofs), len(claim.TokenLeaves))
+	}
+
+	// verify the earner leaf against the root
+	earnerLeaf, err := scheme.EncodeAccountLeaf(claim.EarnerLeaf.Earner, claim.EarnerLeaf.EarnerTokenRoot[:])
+	if err != nil {
+		return err
+	}
+	earnerRoot, err := processInclusionProofKeccak(claim.EarnerTreeProof, crypto.Keccak256(earnerLeaf), claim.EarnerIndex)
+	if err != nil {
+		return fmt.Errorf("invalid earner proof for earner %s: %w", claim.EarnerLeaf.Earner.Hex(), err)
+	}
+	if !bytes.Equal(earnerRoot, root) {
+		return fmt.Errorf("%w for earner %s", ErrInvalidEarnerProof, claim.EarnerLeaf.Earner.Hex())
+	}
+
+	// verify each token leaf against the earner token root
+	for i, leaf := range claim.TokenLeaves {
+		tokenLeaf, err := scheme.EncodeTokenLeaf(leaf.Token, leaf.CumulativeEarnings)
+		if err != nil {
+			return &TokenProofError{Index: i, Err: err}
+		}
+		tokenRoot, err := processInclusionProofKeccak(claim.TokenTreeProofs[i], crypto.Keccak256(tokenLeaf), claim.TokenIndices[i])
+		if err != nil {
+			return &TokenProofError{Index: i, Err: err}
+		}
+		if !bytes.Equal(tokenRoot, claim.EarnerLeaf.EarnerTokenRoot[:]) {
+			return &TokenProofError{Index: i, Err: fmt.Errorf("%w for token %s", ErrTokenRootMismatch, leaf.Token.Hex())}
+		}
+	}
+
+	return nil
+}
+
+// processInclusionProofKeccak walks the proof from the leaf hash up to the root, mirroring Merkle.processInclusionProofKeccak.
+// index must fit in the number of levels of the proof, mirroring the leaf index checks in the RewardsCoordinator.
+func processInclusionProofKeccak(proof []byte, leaf []byte, index uint32) ([]byte, error) {
+	if len(proof)%32 != 0 {
+		return nil, fmt.Errorf("%w - length: %d", ErrInvalidProofLength, len(proof))
+	}
+	levels := len(proof) / 32
+	if levels < 32 && uint64(index) >= uint64(1)<<levels {
+		return nil, fmt.Errorf("%w - index: %d, proof levels: %d", ErrLeafIndexOutOfRange, index, levels)
+	}
+
+	computed := leaf
+	for i := 0; i < len(proof); i += 32 {
+		if index%2 == 0 {
+			computed = crypto.Keccak256(computed, proof[i:i+32])
+		} else {
+			computed = crypto.Keccak256(proof[i:i+32], computed)
+		}
+		index /= 2
+	}
+	return computed, nil
+}
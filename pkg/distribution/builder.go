@@ -0,0 +1,83 @@
+package distribution
+
+import (
+	"fmt"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// DistributionBuilder collects earner, token and amount triples in any order and builds them
+// into a sorted, sealed Distribution.
+type DistributionBuilder struct {
+	leaves     []leaf
+	policy     DuplicatePolicy
+	leafScheme LeafScheme
+	collisions []Collision
+}
+
+// NewDistributionBuilder creates a builder that resolves duplicate earner and token pairs with policy
+func NewDistributionBuilder(policy DuplicatePolicy) *DistributionBuilder {
+	return &DistributionBuilder{
+		leaves: make([]leaf, 0),
+		policy: policy,
+	}
+}
+
+// NewDistributionBuilderWithLeafScheme creates a builder whose distributions are merklized with the given leaf scheme
+func NewDistributionBuilderWithLeafScheme(policy DuplicatePolicy, scheme LeafScheme) *DistributionBuilder {
+	builder := NewDistributionBuilder(policy)
+	builder.leafScheme = scheme
+	return builder
+}
+
+// Add adds an amount for an earner and token. Duplicates are resolved when the distribution is built.
+func (b *DistributionBuilder) Add(earner, token gethcommon.Address, amount *big.Int) error {
+	if amount == nil {
+		return fmt.Errorf("%w - earner: %s, token: %s, amount: nil", ErrInvalidAmount, earner.Hex(), token.Hex())
+	}
+	if err := validateAmount(amount); err != nil {
+		return fmt.Errorf("%w - earner: %s, token: %s", err, earner.Hex(), token.Hex())
+	}
+	b.leaves = append(b.leaves, leaf{earner: earner, token: token, amount: new(big.Int).Set(amount)})
+	return nil
+}
+
+// AddLine parses and adds an EarnerLine
+func (b *DistributionBuilder) AddLine(line *EarnerLine) error {
+	l, err := line.parse()
+	if err != nil {
+		return err
+	}
+	return b.Add(l.earner, l.token, l.amount)
+}
+
+// Len returns the number of amounts added, including duplicates
+func (b *DistributionBuilder) Len() int {
+	return len(b.leaves)
+}
+
+// Collisions returns the duplicates found by the last call to Build
+func (b *DistributionBuilder) Collisions() []Collision {
+	collisions := make([]Collision, len(b.collisions))
+	copy(collisions, b.collisions)
+	return collisions
+}
+
+// Build sorts everything added so far into a new distribution, which is sealed so that it can no longer be Set.
+// Duplicates are resolved in the order they were added. The builder can still be added to and built again.
+func (b *DistributionBuilder) Build() (*Distribution, error) {
+	leaves := make([]leaf, len(b.leaves))
+	copy(leaves, b.leaves)
+
+	distro := NewDistributionWithLeafScheme(b.leafScheme)
+	distro.DuplicatePolicy = b.policy
+	err := distro.setLeaves(leaves)
+	b.collisions = distro.Collisions()
+	if err != nil {
+		return nil, err
+	}
+
+	distro.sealed = true
+	return distro, nil
+}
@@ -0,0 +1,90 @@
+package distribution_test
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistributionBuilder(t *testing.T) {
+	type triple struct {
+		earner, token common.Address
+		amount        int64
+	}
+	triples := make([]triple, 0)
+	for i := 0; i < len(tests.TestAddresses); i++ {
+		for j := 0; j < len(tests.TestTokens)-i; j++ {
+			triples = append(triples, triple{tests.TestAddresses[i], tests.TestTokens[j], int64(j + i + 1)})
+		}
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(triples), func(i, j int) {
+		triples[i], triples[j] = triples[j], triples[i]
+	})
+
+	builder := distribution.NewDistributionBuilder(distribution.DuplicatePolicyReject)
+	for _, tr := range triples {
+		assert.Nil(t, builder.Add(tr.earner, tr.token, big.NewInt(tr.amount)))
+	}
+	assert.Equal(t, len(triples), builder.Len())
+
+	built, err := builder.Build()
+	assert.Nil(t, err)
+	assert.True(t, built.IsSealed())
+
+	expectedAccountTree, _, err := GetTestDistribution().Merklize()
+	assert.Nil(t, err)
+	accountTree, _, err := built.Merklize()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedAccountTree.Root(), accountTree.Root())
+
+	err = built.Set(tests.TestAddresses[4], tests.TestTokens[4], big.NewInt(1))
+	assert.ErrorIs(t, err, distribution.ErrDistributionSealed)
+}
+
+func TestDistributionBuilderDuplicates(t *testing.T) {
+	add := func(builder *distribution.DistributionBuilder) {
+		assert.Nil(t, builder.Add(tests.TestAddresses[1], tests.TestTokens[0], big.NewInt(2)))
+		assert.Nil(t, builder.Add(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1)))
+		assert.Nil(t, builder.Add(tests.TestAddresses[1], tests.TestTokens[0], big.NewInt(7)))
+	}
+
+	builder := distribution.NewDistributionBuilder(distribution.DuplicatePolicyReject)
+	add(builder)
+	_, err := builder.Build()
+	assert.ErrorIs(t, err, distribution.ErrDuplicateLeaf)
+	assert.Len(t, builder.Collisions(), 1)
+
+	builder = distribution.NewDistributionBuilder(distribution.DuplicatePolicyKeepMax)
+	add(builder)
+	built, err := builder.Build()
+	assert.Nil(t, err)
+	amount, _ := built.Get(tests.TestAddresses[1], tests.TestTokens[0])
+	assert.Equal(t, big.NewInt(7), amount)
+	assert.Equal(t, []distribution.Collision{{
+		Earner:   tests.TestAddresses[1],
+		Token:    tests.TestTokens[0],
+		Existing: big.NewInt(2),
+		Incoming: big.NewInt(7),
+	}}, builder.Collisions())
+}
+
+func TestDistributionBuilderCopiesAmounts(t *testing.T) {
+	builder := distribution.NewDistributionBuilder(distribution.DuplicatePolicyReject)
+
+	amount := big.NewInt(5)
+	assert.Nil(t, builder.Add(tests.TestAddresses[0], tests.TestTokens[0], amount))
+	amount.SetInt64(6)
+
+	built, err := builder.Build()
+	assert.Nil(t, err)
+	fetched, _ := built.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.Equal(t, big.NewInt(5), fetched)
+
+	assert.ErrorIs(t, builder.Add(tests.TestAddresses[0], tests.TestTokens[0], nil), distribution.ErrInvalidAmount)
+	assert.ErrorIs(t, builder.Add(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(-1)), distribution.ErrInvalidAmount)
+}
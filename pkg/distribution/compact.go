@@ -0,0 +1,267 @@
+package distribution
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"sort"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// NewCompactDistribution creates an empty distribution stored in sorted contiguous arrays rather than ordered maps,
+// for snapshots with millions of leafs. Each leaf costs a token address and a 32 byte amount, and indices are
+// found by binary search instead of being kept in maps.
+//
+// It has the same API as NewDistribution and produces identical trees. Appending earners and tokens in order
+// is cheap, while adding tokens to an earlier earner or deleting shifts the arrays. Amounts must not be nil.
+func NewCompactDistribution() *Distribution {
+	return &Distribution{store: newCompactStore()}
+}
+
+// NewCompactDistributionFromReader creates a compact distribution from newline delimited EarnerLine JSON
+func NewCompactDistributionFromReader(r io.Reader) (*Distribution, error) {
+	distro := NewCompactDistribution()
+	if err := distro.LoadFromReader(r); err != nil {
+		return nil, err
+	}
+	return distro, nil
+}
+
+// Compact copies the distribution into a compact distribution, see NewCompactDistribution
+func (d *Distribution) Compact() (*Distribution, error) {
+	if d.reportingView {
+		return nil, ErrReportingView
+	}
+	distro := NewCompactDistributionWithLeafScheme(d.leafScheme)
+	distro.DuplicatePolicy = d.DuplicatePolicy
+	distro.QuoteAmounts = d.QuoteAmounts
+	var err error
+	d.ForEachLeaf(func(earner, token gethcommon.Address, amount *big.Int) bool {
+		err = distro.Set(earner, token, amount)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return distro, nil
+}
+
+// IsCompact returns whether the distribution is stored in contiguous arrays, see NewCompactDistribution
+func (d *Distribution) IsCompact() bool {
+	_, compact := d.store.(*compactStore)
+	return compact
+}
+
+// compactStore keeps the leafs in sorted contiguous arrays
+type compactStore struct {
+	earners []gethcommon.Address
+	// the tokens of earners[i] are tokens[tokenOffsets[i]:tokenOffsets[i+1]]
+	tokenOffsets []int
+	tokens       []gethcommon.Address
+	amounts      [][32]byte
+}
+
+func newCompactStore() *compactStore {
+	return &compactStore{
+		earners:      make([]gethcommon.Address, 0),
+		tokenOffsets: []int{0},
+		tokens:       make([]gethcommon.Address, 0),
+		amounts:      make([][32]byte, 0),
+	}
+}
+
+func (s *compactStore) earnerCount() int {
+	return len(s.earners)
+}
+
+func (s *compactStore) leafCount() int {
+	return len(s.tokens)
+}
+
+func (s *compactStore) tokenCount(earner gethcommon.Address) (int, bool) {
+	i, found := s.findEarner(earner)
+	if !found {
+		return 0, false
+	}
+	return s.tokenOffsets[i+1] - s.tokenOffsets[i], true
+}
+
+func (s *compactStore) get(earner, token gethcommon.Address) (*big.Int, bool) {
+	j, found := s.findLeaf(earner, token)
+	if !found {
+		return nil, false
+	}
+	return new(big.Int).SetBytes(s.amounts[j][:]), true
+}
+
+func (s *compactStore) forEachEarner(fn func(earner gethcommon.Address) bool) {
+	for _, earner := range s.earners {
+		if !fn(earner) {
+			return
+		}
+	}
+}
+
+func (s *compactStore) forEachToken(earner gethcommon.Address, fn func(token gethcommon.Address, amount *big.Int) bool) bool {
+	i, found := s.findEarner(earner)
+	if !found {
+		return false
+	}
+	for j := s.tokenOffsets[i]; j < s.tokenOffsets[i+1]; j++ {
+		if !fn(s.tokens[j], new(big.Int).SetBytes(s.amounts[j][:])) {
+			break
+		}
+	}
+	return true
+}
+
+func (s *compactStore) lastEarner() (gethcommon.Address, bool) {
+	if len(s.earners) == 0 {
+		return gethcommon.Address{}, false
+	}
+	return s.earners[len(s.earners)-1], true
+}
+
+func (s *compactStore) lastToken(earner gethcommon.Address) (gethcommon.Address, bool) {
+	i, found := s.findEarner(earner)
+	if !found || s.tokenOffsets[i+1] == s.tokenOffsets[i] {
+		return gethcommon.Address{}, false
+	}
+	return s.tokens[s.tokenOffsets[i+1]-1], true
+}
+
+func (s *compactStore) appendLeaf(earner, token gethcommon.Address, amount *big.Int) error {
+	packed, err := packAmount(earner, token, amount)
+	if err != nil {
+		return err
+	}
+	i, found := s.findEarner(earner)
+	if !found {
+		s.earners = append(s.earners, earner)
+		s.tokenOffsets = append(s.tokenOffsets, len(s.tokens))
+	}
+	// the token goes at the end of the earner's tokens, shifting the tokens of any later earners
+	end := s.tokenOffsets[i+1]
+	s.tokens = slices.Insert(s.tokens, end, token)
+	s.amounts = slices.Insert(s.amounts, end, packed)
+	for k := i + 1; k < len(s.tokenOffsets); k++ {
+		s.tokenOffsets[k]++
+	}
+	return nil
+}
+
+func (s *compactStore) setAmount(earner, token gethcommon.Address, amount *big.Int) error {
+	packed, err := packAmount(earner, token, amount)
+	if err != nil {
+		return err
+	}
+	j, _ := s.findLeaf(earner, token)
+	s.amounts[j] = packed
+	return nil
+}
+
+func (s *compactStore) deleteEarner(earner gethcommon.Address) bool {
+	i, found := s.findEarner(earner)
+	if !found {
+		return false
+	}
+	start, end := s.tokenOffsets[i], s.tokenOffsets[i+1]
+	s.tokens = slices.Delete(s.tokens, start, end)
+	s.amounts = slices.Delete(s.amounts, start, end)
+	s.earners = slices.Delete(s.earners, i, i+1)
+	s.tokenOffsets = slices.Delete(s.tokenOffsets, i+1, i+2)
+	for k := i + 1; k < len(s.tokenOffsets); k++ {
+		s.tokenOffsets[k] -= end - start
+	}
+	return true
+}
+
+func (s *compactStore) deleteToken(earner, token gethcommon.Address) bool {
+	i, found := s.findEarner(earner)
+	if !found {
+		return false
+	}
+	j, found := s.findToken(i, token)
+	if !found {
+		return false
+	}
+	if s.tokenOffsets[i+1]-s.tokenOffsets[i] == 1 {
+		return s.deleteEarner(earner)
+	}
+	s.tokens = slices.Delete(s.tokens, s.tokenOffsets[i]+j, s.tokenOffsets[i]+j+1)
+	s.amounts = slices.Delete(s.amounts, s.tokenOffsets[i]+j, s.tokenOffsets[i]+j+1)
+	for k := i + 1; k < len(s.tokenOffsets); k++ {
+		s.tokenOffsets[k]--
+	}
+	return true
+}
+
+// index is a no-op, the indices are found by binary search
+func (s *compactStore) index() {}
+
+func (s *compactStore) accountIndex(earner gethcommon.Address) (uint64, bool) {
+	i, found := s.findEarner(earner)
+	return uint64(i), found
+}
+
+func (s *compactStore) tokenIndex(earner, token gethcommon.Address) (uint64, bool) {
+	i, found := s.findEarner(earner)
+	if !found {
+		return 0, false
+	}
+	j, found := s.findToken(i, token)
+	return uint64(j), found
+}
+
+func (s *compactStore) clone() leafStore {
+	return &compactStore{
+		earners:      slices.Clone(s.earners),
+		tokenOffsets: slices.Clone(s.tokenOffsets),
+		tokens:       slices.Clone(s.tokens),
+		amounts:      slices.Clone(s.amounts),
+	}
+}
+
+func (s *compactStore) empty() leafStore {
+	return newCompactStore()
+}
+
+func (s *compactStore) findEarner(address gethcommon.Address) (int, bool) {
+	i := sort.Search(len(s.earners), func(i int) bool {
+		return s.earners[i].Cmp(address) >= 0
+	})
+	return i, i < len(s.earners) && s.earners[i] == address
+}
+
+// findToken returns the index of the token within the earner's tokens
+func (s *compactStore) findToken(earnerIndex int, token gethcommon.Address) (int, bool) {
+	tokens := s.tokens[s.tokenOffsets[earnerIndex]:s.tokenOffsets[earnerIndex+1]]
+	j := sort.Search(len(tokens), func(j int) bool {
+		return tokens[j].Cmp(token) >= 0
+	})
+	return j, j < len(tokens) && tokens[j] == token
+}
+
+// findLeaf returns the index of the earner and token in the tokens and amounts arrays
+func (s *compactStore) findLeaf(earner, token gethcommon.Address) (int, bool) {
+	i, found := s.findEarner(earner)
+	if !found {
+		return 0, false
+	}
+	j, found := s.findToken(i, token)
+	return s.tokenOffsets[i] + j, found
+}
+
+// packAmount converts an amount to 32 bytes, nil cannot be stored
+func packAmount(earner, token gethcommon.Address, amount *big.Int) ([32]byte, error) {
+	if amount == nil {
+		return [32]byte{}, fmt.Errorf("%w - earner: %s, token: %s, amount: nil", ErrInvalidAmount, earner.Hex(), token.Hex())
+	}
+	if err := validateAmount(amount); err != nil {
+		return [32]byte{}, fmt.Errorf("%w - earner: %s, token: %s", err, earner.Hex(), token.Hex())
+	}
+	return uint256.MustFromBig(amount).Bytes32(), nil
+}
@@ -0,0 +1,178 @@
+package distribution_test
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactDistributionFromReader(t *testing.T) {
+	d, err := distribution.NewDistributionFromReader(strings.NewReader(getFullTestEarnerLines()))
+	assert.Nil(t, err)
+	c, err := distribution.NewCompactDistributionFromReader(strings.NewReader(getFullTestEarnerLines()))
+	assert.Nil(t, err)
+
+	accountTree, tokenTrees, err := d.Merklize()
+	assert.Nil(t, err)
+	compactAccountTree, compactTokenTrees, err := c.Merklize()
+	assert.Nil(t, err)
+
+	assert.Equal(t, accountTree.Root(), compactAccountTree.Root())
+	assert.Equal(t, accountTree.Nodes, compactAccountTree.Nodes)
+	assert.Equal(t, d.EarnerCount(), c.EarnerCount())
+	for earner, tokenTree := range tokenTrees {
+		assert.Equal(t, tokenTree.Nodes, compactTokenTrees[earner].Nodes)
+	}
+
+	d.ForEachLeaf(func(earner, token common.Address, amount *big.Int) bool {
+		compactAmount, found := c.Get(earner, token)
+		assert.True(t, found)
+		assert.Equal(t, amount, compactAmount)
+
+		accountIndex, _ := d.GetAccountIndex(earner)
+		compactAccountIndex, found := c.GetAccountIndex(earner)
+		assert.True(t, found)
+		assert.Equal(t, accountIndex, compactAccountIndex)
+
+		tokenIndex, _ := d.GetTokenIndex(earner, token)
+		compactTokenIndex, found := c.GetTokenIndex(earner, token)
+		assert.True(t, found)
+		assert.Equal(t, tokenIndex, compactTokenIndex)
+		return true
+	})
+}
+
+func TestCompact(t *testing.T) {
+	d := GetTestDistribution()
+	c, err := d.Compact()
+	assert.Nil(t, err)
+
+	accountTree, err := d.MerklizeAccounts()
+	assert.Nil(t, err)
+	compactAccountTree, err := c.MerklizeAccounts()
+	assert.Nil(t, err)
+	assert.Equal(t, accountTree.Root(), compactAccountTree.Root())
+
+	for _, address := range tests.TestAddresses {
+		tokenTree, err := d.GetTokenTree(address)
+		assert.Nil(t, err)
+		compactTokenTree, err := c.GetTokenTree(address)
+		assert.Nil(t, err)
+		assert.Equal(t, tokenTree.Root(), compactTokenTree.Root())
+		assert.Equal(t, d.TokenCount(address), c.TokenCount(address))
+	}
+
+	_, err = c.GetTokenTree(tests.TestTokens[0])
+	assert.ErrorIs(t, err, distribution.ErrEarnerNotFound)
+}
+
+func TestCompactDistributionSet(t *testing.T) {
+	c := distribution.NewCompactDistribution()
+
+	assert.Nil(t, c.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1)))
+	assert.Nil(t, c.Set(tests.TestAddresses[0], tests.TestTokens[1], big.NewInt(2)))
+	assert.Nil(t, c.Set(tests.TestAddresses[1], tests.TestTokens[0], big.NewInt(3)))
+	assert.Equal(t, 3, c.LeafCount())
+
+	// tokens can still be added to earlier earners
+	assert.Nil(t, c.Set(tests.TestAddresses[0], tests.TestTokens[2], big.NewInt(4)))
+	assert.Equal(t, 4, c.LeafCount())
+	amount, found := c.Get(tests.TestAddresses[0], tests.TestTokens[2])
+	assert.True(t, found)
+	assert.Equal(t, big.NewInt(4), amount)
+
+	err := c.Set(common.HexToAddress("0x01"), tests.TestTokens[0], big.NewInt(4))
+	assert.ErrorIs(t, err, distribution.ErrAddressNotInOrder)
+
+	assert.Nil(t, c.Set(tests.TestAddresses[1], tests.TestTokens[2], big.NewInt(4)))
+	err = c.Set(tests.TestAddresses[1], tests.TestTokens[1], big.NewInt(5))
+	assert.ErrorIs(t, err, distribution.ErrTokenNotInOrder)
+
+	err = c.Set(tests.TestAddresses[2], tests.TestTokens[0], nil)
+	assert.ErrorIs(t, err, distribution.ErrInvalidAmount)
+	err = c.Set(tests.TestAddresses[2], tests.TestTokens[0], big.NewInt(-1))
+	assert.ErrorIs(t, err, distribution.ErrInvalidAmount)
+
+	// duplicates are resolved by the policy
+	err = c.Set(tests.TestAddresses[1], tests.TestTokens[2], big.NewInt(6))
+	assert.ErrorIs(t, err, distribution.ErrDuplicateLeaf)
+	c.DuplicatePolicy = distribution.DuplicatePolicySum
+	assert.Nil(t, c.Set(tests.TestAddresses[1], tests.TestTokens[2], big.NewInt(6)))
+	amount, found = c.Get(tests.TestAddresses[1], tests.TestTokens[2])
+	assert.True(t, found)
+	assert.Equal(t, big.NewInt(10), amount)
+	assert.Len(t, c.Collisions(), 2)
+
+	_, found = c.Get(tests.TestAddresses[1], tests.TestTokens[1])
+	assert.False(t, found)
+	_, found = c.Get(tests.TestAddresses[2], tests.TestTokens[0])
+	assert.False(t, found)
+}
+
+func TestCompactDistributionIndicesRequireMerklize(t *testing.T) {
+	c := distribution.NewCompactDistribution()
+	assert.Nil(t, c.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1)))
+	assert.Nil(t, c.Set(tests.TestAddresses[1], tests.TestTokens[0], big.NewInt(2)))
+	assert.Nil(t, c.Set(tests.TestAddresses[1], tests.TestTokens[1], big.NewInt(3)))
+
+	_, found := c.GetAccountIndex(tests.TestAddresses[0])
+	assert.False(t, found)
+
+	_, err := c.MerklizeAccounts()
+	assert.Nil(t, err)
+	assert.True(t, c.IsMerklized())
+	index, found := c.GetTokenIndex(tests.TestAddresses[1], tests.TestTokens[1])
+	assert.True(t, found)
+	assert.Equal(t, uint64(1), index)
+
+	// setting a leaf invalidates the indices
+	assert.Nil(t, c.Set(tests.TestAddresses[2], tests.TestTokens[0], big.NewInt(4)))
+	assert.False(t, c.IsMerklized())
+	_, found = c.GetAccountIndex(tests.TestAddresses[0])
+	assert.False(t, found)
+}
+
+func TestCompactDistributionWorksWithDistributionTools(t *testing.T) {
+	d, err := distribution.NewDistributionFromReader(strings.NewReader(getFullTestEarnerLines()))
+	assert.Nil(t, err)
+	c, err := distribution.NewCompactDistributionFromReader(strings.NewReader(getFullTestEarnerLines()))
+	assert.Nil(t, err)
+	assert.True(t, c.IsCompact())
+	assert.False(t, d.IsCompact())
+
+	assert.True(t, distribution.DiffDistributions(d, c).IsEmpty())
+	assert.Nil(t, distribution.ValidateMonotonicity(d, c))
+	assert.Equal(t, d.Summarize(3), c.Summarize(3))
+
+	filter := distribution.Filter{Tokens: []common.Address{common.HexToAddress("0x94373a4919b3240d86ea41593d5eba789fef3848")}}
+	view := c.Filter(filter)
+	assert.True(t, view.IsCompact())
+	assert.True(t, distribution.DiffDistributions(d.Filter(filter), view).IsEmpty())
+
+	accountTree, _, err := c.Merklize()
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	assert.Nil(t, distribution.WriteSnapshot(&buf, c, accountTree, time.Unix(1716681600, 0).UTC()))
+	snapshot, err := distribution.ReadSnapshot(&buf)
+	assert.Nil(t, err)
+	assert.True(t, distribution.DiffDistributions(d, snapshot.Distribution).IsEmpty())
+
+	frozen, err := c.Freeze()
+	assert.Nil(t, err)
+	expected, err := d.Freeze()
+	assert.Nil(t, err)
+	assert.Equal(t, expected.Root(), frozen.Root())
+
+	dump, err := frozen.Dump()
+	assert.Nil(t, err)
+	expectedDump, err := expected.Dump()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedDump, dump)
+}
@@ -0,0 +1,156 @@
+package distribution
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+var ErrInvalidCSVHeader = errors.New("invalid CSV header")
+
+// csvHeader is the header written by WriteCSV and required by LoadFromCSV, in any column order
+var csvHeader = []string{"earner", "token", "cumulative_amount"}
+
+// WriteNDJSON writes the distribution as newline delimited EarnerLine JSON in tree order,
+// the format read by LoadFromReader
+func (d *Distribution) WriteNDJSON(w io.Writer) error {
+	return writeNDJSON(w, d.ForEachLeaf)
+}
+
+// WriteCSV writes the distribution as CSV with an earner,token,cumulative_amount header, in tree order
+func (d *Distribution) WriteCSV(w io.Writer) error {
+	return writeCSV(w, d.ForEachLeaf)
+}
+
+// NewDistributionFromCSV creates a distribution from CSV written by WriteCSV
+func NewDistributionFromCSV(r io.Reader) (*Distribution, error) {
+	distro := NewDistribution()
+	if err := distro.LoadFromCSV(r); err != nil {
+		return nil, err
+	}
+	return distro, nil
+}
+
+// LoadFromCSV loads CSV with an earner, token and cumulative_amount column into the distribution.
+// The header row is required but its columns may be in any order, and extra columns are ignored.
+// Like LoadFromReader, rows may appear in any order and if any row is invalid nothing is set
+// and an *InvalidLinesError listing every invalid row is returned.
+func (d *Distribution) LoadFromCSV(r io.Reader) error {
+	leaves, err := readCSVLeaves(r)
+	if err != nil {
+		return err
+	}
+	return d.setLeaves(leaves)
+}
+
+func readCSVLeaves(r io.Reader) ([]leaf, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: missing header", ErrInvalidCSVHeader)
+		}
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	indices := make([]int, len(csvHeader))
+	for i, name := range csvHeader {
+		index, found := columns[name]
+		if !found {
+			return nil, fmt.Errorf("%w: missing column '%s'", ErrInvalidCSVHeader, name)
+		}
+		indices[i] = index
+	}
+
+	leaves := make([]leaf, 0)
+	invalid := make([]*LineError, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				invalid = append(invalid, &LineError{Line: parseErr.Line, Err: err})
+				continue
+			}
+			return nil, err
+		}
+		lineNumber, _ := reader.FieldPos(0)
+
+		if len(record) <= max(indices[0], indices[1], indices[2]) {
+			invalid = append(invalid, &LineError{Line: lineNumber, Err: fmt.Errorf("expected at least %d columns, got %d", len(header), len(record))})
+			continue
+		}
+		line := EarnerLine{
+			Earner:           strings.TrimSpace(record[indices[0]]),
+			Token:            strings.TrimSpace(record[indices[1]]),
+			CumulativeAmount: strings.TrimSpace(record[indices[2]]),
+		}
+		l, err := line.parse()
+		if err != nil {
+			invalid = append(invalid, &LineError{Line: lineNumber, Err: err})
+			continue
+		}
+		leaves = append(leaves, l)
+	}
+	if len(invalid) > 0 {
+		return nil, &InvalidLinesError{Lines: invalid}
+	}
+	return leaves, nil
+}
+
+func writeNDJSON(w io.Writer, forEachLeaf func(func(earner, token gethcommon.Address, amount *big.Int) bool)) error {
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	var err error
+	forEachLeaf(func(earner, token gethcommon.Address, amount *big.Int) bool {
+		err = encoder.Encode(newEarnerLine(earner, token, amount))
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func writeCSV(w io.Writer, forEachLeaf func(func(earner, token gethcommon.Address, amount *big.Int) bool)) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	var err error
+	forEachLeaf(func(earner, token gethcommon.Address, amount *big.Int) bool {
+		line := newEarnerLine(earner, token, amount)
+		err = writer.Write([]string{line.Earner, line.Token, line.CumulativeAmount})
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// newEarnerLine formats a leaf the way the claim amounts files do, with lowercase addresses
+func newEarnerLine(earner, token gethcommon.Address, amount *big.Int) *EarnerLine {
+	return &EarnerLine{
+		Earner:           strings.ToLower(earner.Hex()),
+		Token:            strings.ToLower(token.Hex()),
+		CumulativeAmount: orZero(amount).String(),
+	}
+}
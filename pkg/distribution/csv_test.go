@@ -0,0 +1,99 @@
+package distribution_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteNDJSONRoundTrip(t *testing.T) {
+	d, err := distribution.NewDistributionFromReader(strings.NewReader(getFullTestEarnerLines()))
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, d.WriteNDJSON(&buf))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, `{"earner":"0x02c0e523aa4797727c464816ad37f40723b472ef","token":"0x94373a4919b3240d86ea41593d5eba789fef3848","cumulative_amount":"6102895758009265"}`, lines[0])
+
+	reloaded, err := distribution.NewDistributionFromReader(&buf)
+	assert.Nil(t, err)
+	assert.True(t, distribution.DiffDistributions(d, reloaded).IsEmpty())
+}
+
+func TestWriteCSVRoundTrip(t *testing.T) {
+	d := GetTestDistribution()
+
+	var buf bytes.Buffer
+	assert.Nil(t, d.WriteCSV(&buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "earner,token,cumulative_amount\n"))
+
+	reloaded, err := distribution.NewDistributionFromCSV(&buf)
+	assert.Nil(t, err)
+	assert.True(t, distribution.DiffDistributions(d, reloaded).IsEmpty())
+
+	accountTree, _, err := d.Merklize()
+	assert.Nil(t, err)
+	reloadedAccountTree, _, err := reloaded.Merklize()
+	assert.Nil(t, err)
+	assert.Equal(t, accountTree.Root(), reloadedAccountTree.Root())
+
+	c, err := d.Compact()
+	assert.Nil(t, err)
+	var compactBuf bytes.Buffer
+	assert.Nil(t, c.WriteCSV(&compactBuf))
+	var ndjsonBuf, compactNDJSONBuf bytes.Buffer
+	assert.Nil(t, d.WriteNDJSON(&ndjsonBuf))
+	assert.Nil(t, c.WriteNDJSON(&compactNDJSONBuf))
+	assert.Nil(t, d.WriteCSV(&buf))
+	assert.Equal(t, buf.String(), compactBuf.String())
+	assert.Equal(t, ndjsonBuf.String(), compactNDJSONBuf.String())
+}
+
+func TestLoadFromCSV(t *testing.T) {
+	input := `cumulative_amount,token,earner,note
+2,0x94373a4919b3240d86ea41593d5eba789fef3848,0xce50089021676aa2cbac4cc72a2aa655b495bc73,b
+1,0x94373a4919b3240d86ea41593d5eba789fef3848,0x0018a2bf2f8ee5eef2c1a8ae3ae3d1df9d2ba8e0,a
+`
+	d, err := distribution.NewDistributionFromCSV(strings.NewReader(input))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, d.EarnerCount())
+
+	var buf bytes.Buffer
+	assert.Nil(t, d.WriteCSV(&buf))
+	assert.Equal(t, `earner,token,cumulative_amount
+0x0018a2bf2f8ee5eef2c1a8ae3ae3d1df9d2ba8e0,0x94373a4919b3240d86ea41593d5eba789fef3848,1
+0xce50089021676aa2cbac4cc72a2aa655b495bc73,0x94373a4919b3240d86ea41593d5eba789fef3848,2
+`, buf.String())
+}
+
+func TestLoadFromCSVInvalid(t *testing.T) {
+	_, err := distribution.NewDistributionFromCSV(strings.NewReader(""))
+	assert.ErrorIs(t, err, distribution.ErrInvalidCSVHeader)
+
+	_, err = distribution.NewDistributionFromCSV(strings.NewReader("earner,token\n"))
+	assert.ErrorIs(t, err, distribution.ErrInvalidCSVHeader)
+
+	input := `earner,token,cumulative_amount
+0xce50089021676aa2cbac4cc72a2aa655b495bc73,0x94373a4919b3240d86ea41593d5eba789fef3848,1
+0xnotanaddress,0x94373a4919b3240d86ea41593d5eba789fef3848,1
+0x0018a2bf2f8ee5eef2c1a8ae3ae3d1df9d2ba8e0,0x94373a4919b3240d86ea41593d5eba789fef3848,-1
+0x0018a2bf2f8ee5eef2c1a8ae3ae3d1df9d2ba8e0
+`
+	d := distribution.NewDistribution()
+	err = d.LoadFromCSV(strings.NewReader(input))
+
+	var invalidLines *distribution.InvalidLinesError
+	assert.True(t, errors.As(err, &invalidLines))
+	assert.Len(t, invalidLines.Lines, 3)
+	assert.Equal(t, 3, invalidLines.Lines[0].Line)
+	assert.ErrorIs(t, invalidLines.Lines[0], distribution.ErrInvalidAddress)
+	assert.Equal(t, 4, invalidLines.Lines[1].Line)
+	assert.ErrorIs(t, invalidLines.Lines[1], distribution.ErrInvalidAmount)
+	assert.Equal(t, 5, invalidLines.Lines[2].Line)
+	assert.Equal(t, 0, d.EarnerCount())
+}
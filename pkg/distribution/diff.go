@@ -0,0 +1,136 @@
+package distribution
+
+import (
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// TokenAmount is a token and its cumulative amount for an earner
+type TokenAmount struct {
+	Token  gethcommon.Address `json:"token"`
+	Amount *big.Int           `json:"amount"`
+}
+
+// EarnerTokenChanges lists the tokens added to and removed from an earner between two distributions
+type EarnerTokenChanges struct {
+	Earner  gethcommon.Address `json:"earner"`
+	Added   []TokenAmount      `json:"added,omitempty"`
+	Removed []TokenAmount      `json:"removed,omitempty"`
+}
+
+// AmountChange is a change in the amount of a token that an earner has in both distributions
+type AmountChange struct {
+	Earner   gethcommon.Address `json:"earner"`
+	Token    gethcommon.Address `json:"token"`
+	Previous *big.Int           `json:"previous"`
+	Next     *big.Int           `json:"next"`
+	Delta    *big.Int           `json:"delta"`
+}
+
+// Diff is the difference between two distributions, in tree order.
+// Tokens includes the tokens of added and removed earners.
+type Diff struct {
+	EarnersAdded   []gethcommon.Address `json:"earners_added"`
+	EarnersRemoved []gethcommon.Address `json:"earners_removed"`
+	Tokens         []EarnerTokenChanges `json:"tokens"`
+	AmountChanges  []AmountChange       `json:"amount_changes"`
+}
+
+// IsEmpty returns whether the two distributions were identical
+func (d *Diff) IsEmpty() bool {
+	return len(d.EarnersAdded) == 0 && len(d.EarnersRemoved) == 0 && len(d.Tokens) == 0 && len(d.AmountChanges) == 0
+}
+
+// DiffDistributions compares two distributions in a single pass over both.
+// Both must be in address order, as Set enforces.
+func DiffDistributions(prev, next *Distribution) *Diff {
+	diff := &Diff{
+		EarnersAdded:   make([]gethcommon.Address, 0),
+		EarnersRemoved: make([]gethcommon.Address, 0),
+		Tokens:         make([]EarnerTokenChanges, 0),
+		AmountChanges:  make([]AmountChange, 0),
+	}
+
+	mergeSorted(prev.earners(), next.earners(), identity, func(earner gethcommon.Address, inPrev, inNext *gethcommon.Address) {
+		tokenChanges := EarnerTokenChanges{Earner: earner}
+		if inPrev == nil {
+			diff.EarnersAdded = append(diff.EarnersAdded, earner)
+			tokenChanges.Added = next.tokenAmounts(earner)
+		} else if inNext == nil {
+			diff.EarnersRemoved = append(diff.EarnersRemoved, earner)
+			tokenChanges.Removed = prev.tokenAmounts(earner)
+		} else {
+			mergeSorted(prev.tokenAmounts(earner), next.tokenAmounts(earner), tokenOf, func(token gethcommon.Address, prevAmount, nextAmount *TokenAmount) {
+				switch {
+				case prevAmount == nil:
+					tokenChanges.Added = append(tokenChanges.Added, *nextAmount)
+				case nextAmount == nil:
+					tokenChanges.Removed = append(tokenChanges.Removed, *prevAmount)
+				case prevAmount.Amount.Cmp(nextAmount.Amount) != 0:
+					diff.AmountChanges = append(diff.AmountChanges, AmountChange{
+						Earner:   earner,
+						Token:    token,
+						Previous: prevAmount.Amount,
+						Next:     nextAmount.Amount,
+						Delta:    new(big.Int).Sub(nextAmount.Amount, prevAmount.Amount),
+					})
+				}
+			})
+		}
+		if len(tokenChanges.Added) > 0 || len(tokenChanges.Removed) > 0 {
+			diff.Tokens = append(diff.Tokens, tokenChanges)
+		}
+	})
+
+	return diff
+}
+
+// mergeSorted walks two address ordered lists together, calling fn once per address with the element from each list,
+// or nil when the address is not in that list
+func mergeSorted[V any](a, b []V, key func(V) gethcommon.Address, fn func(address gethcommon.Address, a, b *V)) {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && key(a[i]).Cmp(key(b[j])) < 0):
+			fn(key(a[i]), &a[i], nil)
+			i++
+		case i == len(a) || key(b[j]).Cmp(key(a[i])) < 0:
+			fn(key(b[j]), nil, &b[j])
+			j++
+		default:
+			fn(key(a[i]), &a[i], &b[j])
+			i++
+			j++
+		}
+	}
+}
+
+func identity(address gethcommon.Address) gethcommon.Address {
+	return address
+}
+
+func tokenOf(amount TokenAmount) gethcommon.Address {
+	return amount.Token
+}
+
+// earners lists the earners in tree order
+func (d *Distribution) earners() []gethcommon.Address {
+	earners := make([]gethcommon.Address, 0, d.store.earnerCount())
+	d.store.forEachEarner(func(earner gethcommon.Address) bool {
+		earners = append(earners, earner)
+		return true
+	})
+	return earners
+}
+
+// tokenAmounts lists the tokens of an earner in tree order with copies of their amounts, nil amounts as zero
+func (d *Distribution) tokenAmounts(earner gethcommon.Address) []TokenAmount {
+	tokenCount, _ := d.store.tokenCount(earner)
+	amounts := make([]TokenAmount, 0, tokenCount)
+	d.store.forEachToken(earner, func(token gethcommon.Address, amount *big.Int) bool {
+		amounts = append(amounts, TokenAmount{Token: token, Amount: new(big.Int).Set(orZero(amount))})
+		return true
+	})
+	return amounts
+}
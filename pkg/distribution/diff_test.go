@@ -0,0 +1,66 @@
+package distribution_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffDistributionsIdentical(t *testing.T) {
+	diff := distribution.DiffDistributions(GetTestDistribution(), GetTestDistribution())
+	assert.True(t, diff.IsEmpty())
+}
+
+func TestDiffDistributions(t *testing.T) {
+	prev := distribution.NewDistribution()
+	assert.Nil(t, prev.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1)))
+	assert.Nil(t, prev.Set(tests.TestAddresses[0], tests.TestTokens[1], big.NewInt(2)))
+	assert.Nil(t, prev.Set(tests.TestAddresses[1], tests.TestTokens[0], big.NewInt(3)))
+	assert.Nil(t, prev.Set(tests.TestAddresses[3], tests.TestTokens[0], big.NewInt(4)))
+
+	next := distribution.NewDistribution()
+	assert.Nil(t, next.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(5)))
+	assert.Nil(t, next.Set(tests.TestAddresses[0], tests.TestTokens[2], big.NewInt(6)))
+	assert.Nil(t, next.Set(tests.TestAddresses[2], tests.TestTokens[0], big.NewInt(7)))
+	assert.Nil(t, next.Set(tests.TestAddresses[3], tests.TestTokens[0], big.NewInt(4)))
+
+	diff := distribution.DiffDistributions(prev, next)
+	assert.False(t, diff.IsEmpty())
+
+	assert.Equal(t, []common.Address{tests.TestAddresses[2]}, diff.EarnersAdded)
+	assert.Equal(t, []common.Address{tests.TestAddresses[1]}, diff.EarnersRemoved)
+
+	assert.Equal(t, []distribution.EarnerTokenChanges{
+		{
+			Earner:  tests.TestAddresses[0],
+			Added:   []distribution.TokenAmount{{Token: tests.TestTokens[2], Amount: big.NewInt(6)}},
+			Removed: []distribution.TokenAmount{{Token: tests.TestTokens[1], Amount: big.NewInt(2)}},
+		},
+		{
+			Earner:  tests.TestAddresses[1],
+			Removed: []distribution.TokenAmount{{Token: tests.TestTokens[0], Amount: big.NewInt(3)}},
+		},
+		{
+			Earner: tests.TestAddresses[2],
+			Added:  []distribution.TokenAmount{{Token: tests.TestTokens[0], Amount: big.NewInt(7)}},
+		},
+	}, diff.Tokens)
+
+	assert.Equal(t, []distribution.AmountChange{
+		{
+			Earner:   tests.TestAddresses[0],
+			Token:    tests.TestTokens[0],
+			Previous: big.NewInt(1),
+			Next:     big.NewInt(5),
+			Delta:    big.NewInt(4),
+		},
+	}, diff.AmountChanges)
+
+	_, err := json.Marshal(diff)
+	assert.Nil(t, err)
+}
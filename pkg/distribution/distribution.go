@@ -1,23 +1,39 @@
 package distribution
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/holiman/uint256"
 	"github.com/wealdtech/go-merkletree/v2"
-	"github.com/wealdtech/go-merkletree/v2/keccak256"
 	orderedmap "github.com/wk8/go-ordered-map/v2"
 	"math/big"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 var ErrAddressNotInOrder = errors.New("addresses must be added in order")
 var ErrTokenNotInOrder = errors.New("tokens must be added in order")
+var ErrInvalidAddress = errors.New("invalid address")
+var ErrEarnerNotFound = errors.New("earner not found")
+var ErrInvalidAmount = errors.New("amount must be between 0 and 2^256 - 1")
+var ErrInvalidAccountRoot = errors.New("account root must be 32 bytes")
+var ErrTooManyLeafs = errors.New("too many leafs for uint32 indices")
+var ErrDistributionSealed = errors.New("distribution is sealed")
+var ErrTokenNotFound = errors.New("token not found")
+var ErrLossyAmount = errors.New("amount is written with fewer digits than it has and may have lost precision")
+
+// maxLeafs is the most earners, or tokens for a single earner, that the RewardsCoordinator's uint32 indices can address
+const maxLeafs = 1 << 32
+
 var EARNER_LEAF_SALT = []byte{0}
 var TOKEN_LEAF_SALT = []byte{1}
 
 // Used for marshalling and unmarshalling big integers.
+// Amounts are marshalled as bare JSON numbers, see quotedBigInt for the string form.
 type BigInt struct {
 	*big.Int
 }
@@ -26,12 +42,17 @@ func (b BigInt) MarshalJSON() ([]byte, error) {
 	return []byte(b.String()), nil
 }
 
+// UnmarshalJSON accepts both bare integers and integers quoted as decimal strings
 func (b *BigInt) UnmarshalJSON(p []byte) error {
 	if string(p) == "null" {
 		return nil
 	}
+	s := string(p)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
 	var z big.Int
-	_, ok := z.SetString(string(p), 10)
+	_, ok := z.SetString(s, 10)
 	if !ok {
 		return fmt.Errorf("not a valid big integer: %s", p)
 	}
@@ -39,261 +60,694 @@ func (b *BigInt) UnmarshalJSON(p []byte) error {
 	return nil
 }
 
+// quotedBigInt marshals a big integer as a decimal string, which JSON parsers that use
+// float64 numbers, such as JavaScript's, can read without rounding
+type quotedBigInt struct {
+	*big.Int
+}
+
+func (b quotedBigInt) MarshalJSON() ([]byte, error) {
+	if b.Int == nil {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + b.String() + `"`), nil
+}
+
 type Distribution struct {
-	accountIndices map[gethcommon.Address]uint64                        // used for optimizing proving
-	tokenIndices   map[gethcommon.Address]map[gethcommon.Address]uint64 // used for optimizing proving
-	data           *orderedmap.OrderedMap[gethcommon.Address, *orderedmap.OrderedMap[gethcommon.Address, *BigInt]]
-	Debug          bool
+	store         leafStore
+	indexed       bool // set by merklizing, cleared by any change
+	collisions    []Collision
+	sealed        bool // set by DistributionBuilder.Build, prevents further changes
+	reportingView bool // set by Filter, prevents merklizing
+	leafScheme    LeafScheme
+	Debug         bool
+	// QuoteAmounts makes MarshalJSON write amounts as decimal strings instead of bare numbers.
+	// UnmarshalJSON accepts either form regardless.
+	QuoteAmounts bool
+	// DuplicatePolicy decides what happens when an earner and token pair is set more than once.
+	// The zero value is DuplicatePolicyReject, so a duplicate that used to overwrite the amount is now an error.
+	DuplicatePolicy DuplicatePolicy
 }
 
 func NewDistribution() *Distribution {
-	data := orderedmap.New[gethcommon.Address, *orderedmap.OrderedMap[gethcommon.Address, *BigInt]]()
 	return &Distribution{
-		data: data,
+		store: newMapStore(),
 	}
 }
 
 func NewDistributionWithData(initJsonData []byte) (*Distribution, error) {
-	data := orderedmap.New[gethcommon.Address, *orderedmap.OrderedMap[gethcommon.Address, *BigInt]]()
-	distro := &Distribution{
-		data: data,
-	}
-
-	if data != nil {
-		if err := distro.UnmarshalJSON(initJsonData); err != nil {
-			return nil, err
-		}
+	distro := NewDistribution()
+	if err := distro.UnmarshalJSON(initJsonData); err != nil {
+		return nil, err
 	}
 	return distro, nil
 }
 
 type EarnerLine struct {
-	Earner           string `json:"earner"`
-	Token            string `json:"token"`
+	Earner string `json:"earner"`
+	Token  string `json:"token"`
+	// CumulativeAmount is the amount exactly as written, whether the input was a JSON string or number
 	CumulativeAmount string `json:"cumulative_amount"`
+	// Metadata holds any other columns, such as snapshot timestamps, as raw JSON
+	Metadata map[string]json.RawMessage `json:"-"`
 }
 
+// UnmarshalJSON reads the amount from either a JSON string or number without going through a float64,
+// and keeps any other columns in Metadata
+func (e *EarnerLine) UnmarshalJSON(p []byte) error {
+	columns := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(p, &columns); err != nil {
+		return err
+	}
+
+	line := EarnerLine{}
+	for name, raw := range columns {
+		var err error
+		switch name {
+		case "earner":
+			err = json.Unmarshal(raw, &line.Earner)
+		case "token":
+			err = json.Unmarshal(raw, &line.Token)
+		case "cumulative_amount":
+			line.CumulativeAmount, err = unmarshalAmount(raw)
+		default:
+			if line.Metadata == nil {
+				line.Metadata = make(map[string]json.RawMessage)
+			}
+			line.Metadata[name] = raw
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	*e = line
+	return nil
+}
+
+// MarshalJSON writes the amount as a JSON string, followed by any metadata columns in name order
+func (e EarnerLine) MarshalJSON() ([]byte, error) {
+	p, err := json.Marshal(struct {
+		Earner           string `json:"earner"`
+		Token            string `json:"token"`
+		CumulativeAmount string `json:"cumulative_amount"`
+	}{e.Earner, e.Token, e.CumulativeAmount})
+	if err != nil || len(e.Metadata) == 0 {
+		return p, err
+	}
+
+	names := make([]string, 0, len(e.Metadata))
+	for name := range e.Metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf := bytes.NewBuffer(p[:len(p)-1])
+	for _, name := range names {
+		value := e.Metadata[name]
+		if !json.Valid(value) {
+			return nil, fmt.Errorf("invalid metadata column %s: %s", name, value)
+		}
+		key, _ := json.Marshal(name)
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// unmarshalAmount accepts a JSON string, kept as is to be validated by CumulativeAmountBigInt, or a JSON number
+func unmarshalAmount(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return "", fmt.Errorf("expected a string or number, got %s", raw)
+	}
+	return number.String(), nil
+}
+
+// CumulativeAmountBigInt parses the amount exactly.
+// Integers in fraction or exponent form are only accepted when every digit of the integer is written out,
+// such as 1.5e+1 or 15.0. Exports that went through a float64 write only its significant digits, so forms
+// like 1.5e+3 may already have been rounded and return ErrLossyAmount.
 func (e *EarnerLine) CumulativeAmountBigInt() (*big.Int, error) {
-	cumulativeRewards := new(big.Int)
-	cumulativeRewards, success := cumulativeRewards.SetString(e.CumulativeAmount, 10)
-	if !success {
-		return nil, fmt.Errorf("failed to parse cumulative reward: %s", e.CumulativeAmount)
+	s := e.CumulativeAmount
+	if cumulativeRewards, success := new(big.Int).SetString(s, 10); success {
+		return cumulativeRewards, nil
+	}
+
+	isDecimal := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !strings.ContainsRune("0123456789+-.eE", r)
+	}) < 0
+	var r *big.Rat
+	if isDecimal {
+		r, isDecimal = new(big.Rat).SetString(s)
+	}
+	if !isDecimal {
+		return nil, fmt.Errorf("failed to parse cumulative reward: %s", s)
 	}
-	return cumulativeRewards, nil
+	if !r.IsInt() {
+		return nil, fmt.Errorf("%w - not an integer: %s", ErrInvalidAmount, s)
+	}
+	if !hasAllIntegerDigits(s) {
+		return nil, fmt.Errorf("%w: %s", ErrLossyAmount, s)
+	}
+	return r.Num(), nil
 }
 
-func (d *Distribution) loadLine(line *EarnerLine) error {
-	if d.Debug {
-		fmt.Printf("Distribution.loadLine: %v\n", line)
+// hasAllIntegerDigits reports whether a decimal in fraction or exponent form writes out every digit of its
+// integer part, rather than implying trailing zeros with its exponent
+func hasAllIntegerDigits(s string) bool {
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		mantissa = s[:i]
+		if exponent, err = strconv.Atoi(s[i+1:]); err != nil {
+			return false
+		}
+	}
+	fraction := ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		fraction = mantissa[i+1:]
+	}
+	return len(fraction) >= exponent
+}
+
+// ParseAddress parses a 20 byte hex address, with or without the 0x prefix.
+// Unlike gethcommon.HexToAddress it rejects input of the wrong length or containing non-hex characters.
+func ParseAddress(s string) (gethcommon.Address, error) {
+	if !gethcommon.IsHexAddress(s) {
+		return gethcommon.Address{}, fmt.Errorf("%w: '%s'", ErrInvalidAddress, s)
 	}
-	earner := gethcommon.HexToAddress(line.Earner)
-	token := gethcommon.HexToAddress(line.Token)
+	return gethcommon.HexToAddress(s), nil
+}
 
-	cumulativeRewards, err := line.CumulativeAmountBigInt()
+// parse validates the line and converts it to a leaf
+func (e *EarnerLine) parse() (leaf, error) {
+	earner, err := ParseAddress(e.Earner)
 	if err != nil {
-		return err
+		return leaf{}, fmt.Errorf("invalid earner: %w", err)
 	}
-
-	return d.Set(earner, token, cumulativeRewards)
+	token, err := ParseAddress(e.Token)
+	if err != nil {
+		return leaf{}, fmt.Errorf("invalid token: %w", err)
+	}
+	amount, err := e.CumulativeAmountBigInt()
+	if err != nil {
+		return leaf{}, err
+	}
+	if err := validateAmount(amount); err != nil {
+		return leaf{}, err
+	}
+	return leaf{earner: earner, token: token, amount: amount}, nil
 }
 
+// LoadLines validates every line, sorts them by earner and token address and sets them in the distribution.
+// If any line is invalid nothing is set and an *InvalidLinesError listing every invalid line is returned,
+// where each line number is the 1-based position of the line in lines. If a line cannot be set, for example
+// a duplicate under DuplicatePolicyReject, nothing is set either.
 func (d *Distribution) LoadLines(lines []*EarnerLine) error {
 	if d.Debug {
 		fmt.Printf("Lines before sort: %v\n", lines)
 	}
-	sort.Slice(lines, func(i, j int) bool {
-		return fmt.Sprintf("%s%s", lines[i].Earner, lines[i].Token) < fmt.Sprintf("%s%s", lines[j].Earner, lines[j].Token)
-	})
-	if d.Debug {
-		fmt.Printf("Lines after sort: %v\n", lines)
+	leaves := make([]leaf, 0, len(lines))
+	invalid := make([]*LineError, 0)
+	for i, line := range lines {
+		l, err := line.parse()
+		if err != nil {
+			invalid = append(invalid, &LineError{Line: i + 1, Err: err})
+			continue
+		}
+		leaves = append(leaves, l)
+	}
+	if len(invalid) > 0 {
+		return &InvalidLinesError{Lines: invalid}
+	}
+
+	return d.setLeaves(leaves)
+}
+
+// setLeaves sorts the leaves by earner and token and sets them in the distribution, or none of them if any fails.
+// Under DuplicatePolicyReject every duplicate is recorded before the load fails.
+func (d *Distribution) setLeaves(leaves []leaf) error {
+	if d.sealed {
+		return ErrDistributionSealed
 	}
-	for _, l := range lines {
-		if err := d.loadLine(l); err != nil {
+	// set the leaves on a copy, so that a failure leaves the distribution as it was
+	staged := d.clone()
+	err := setSortedLeaves(leaves, staged.Set)
+	d.collisions = append(d.collisions, staged.collisions...)
+	if err != nil {
+		return err
+	}
+	d.store = staged.store
+	d.invalidateIndices()
+	return nil
+}
+
+// setSortedLeaves sorts the leaves by earner and token and sets each of them with set,
+// carrying on past duplicates so they are all recorded
+func setSortedLeaves(leaves []leaf, set func(address, token gethcommon.Address, amount *big.Int) error) error {
+	sort.SliceStable(leaves, func(i, j int) bool {
+		if c := leaves[i].earner.Cmp(leaves[j].earner); c != 0 {
+			return c < 0
+		}
+		return leaves[i].token.Cmp(leaves[j].token) < 0
+	})
+	duplicates := 0
+	for _, l := range leaves {
+		err := set(l.earner, l.token, l.amount)
+		if errors.Is(err, ErrDuplicateLeaf) {
+			duplicates++
+			continue
+		}
+		if err != nil {
 			return err
 		}
 	}
+	if duplicates > 0 {
+		return fmt.Errorf("%w - found %d duplicates", ErrDuplicateLeaf, duplicates)
+	}
 	return nil
 }
 
+// IsSealed returns whether the distribution was built by a DistributionBuilder and can no longer be changed
+func (d *Distribution) IsSealed() bool {
+	return d.sealed
+}
+
 func (d *Distribution) MarshalJSON() ([]byte, error) {
-	return d.data.MarshalJSON()
+	if !d.QuoteAmounts {
+		if store, ok := d.store.(*mapStore); ok {
+			return store.data.MarshalJSON()
+		}
+		return d.copyData().MarshalJSON()
+	}
+	quoted := orderedmap.New[gethcommon.Address, *orderedmap.OrderedMap[gethcommon.Address, quotedBigInt]](d.store.earnerCount())
+	d.store.forEachEarner(func(earner gethcommon.Address) bool {
+		tokens := orderedmap.New[gethcommon.Address, quotedBigInt]()
+		d.store.forEachToken(earner, func(token gethcommon.Address, amount *big.Int) bool {
+			tokens.Set(token, quotedBigInt{amount})
+			return true
+		})
+		quoted.Set(earner, tokens)
+		return true
+	})
+	return quoted.MarshalJSON()
 }
 
 func (d *Distribution) UnmarshalJSON(p []byte) error {
+	if d.sealed {
+		return ErrDistributionSealed
+	}
 	data := orderedmap.New[gethcommon.Address, *orderedmap.OrderedMap[gethcommon.Address, *BigInt]]()
 	err := data.UnmarshalJSON(p)
 	if err != nil {
 		return err
 	}
-	d.data = data
+	// the trees, diffs and validation rely on the order, so JSON written in any order is sorted
+	accountPairs := sortedPairs(data)
+	store := d.store.empty()
+	for _, accountPair := range accountPairs {
+		for _, tokenPair := range sortedPairs(accountPair.Value) {
+			if err := store.appendLeaf(accountPair.Key, tokenPair.Key, tokenPair.Value.Int); err != nil {
+				return err
+			}
+		}
+	}
+	d.store = store
+	d.invalidateIndices()
 	return nil
 }
 
+// sortedPairs returns the pairs of an ordered map in address order
+func sortedPairs[V any](m *orderedmap.OrderedMap[gethcommon.Address, V]) []*orderedmap.Pair[gethcommon.Address, V] {
+	pairs := make([]*orderedmap.Pair[gethcommon.Address, V], 0, m.Len())
+	for pair := m.Oldest(); pair != nil; pair = pair.Next() {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key.Cmp(pairs[j].Key) < 0
+	})
+	return pairs
+}
+
+// copyData copies the distribution into ordered maps
+func (d *Distribution) copyData() *orderedmap.OrderedMap[gethcommon.Address, *orderedmap.OrderedMap[gethcommon.Address, *BigInt]] {
+	data := orderedmap.New[gethcommon.Address, *orderedmap.OrderedMap[gethcommon.Address, *BigInt]](d.store.earnerCount())
+	d.store.forEachEarner(func(earner gethcommon.Address) bool {
+		data.Set(earner, d.copyTokens(earner))
+		return true
+	})
+	return data
+}
+
+// copyTokens copies the tokens of an earner into an ordered map
+func (d *Distribution) copyTokens(earner gethcommon.Address) *orderedmap.OrderedMap[gethcommon.Address, *BigInt] {
+	tokens := orderedmap.New[gethcommon.Address, *BigInt]()
+	d.store.forEachToken(earner, func(token gethcommon.Address, amount *big.Int) bool {
+		tokens.Set(token, &BigInt{Int: copyBigInt(amount)})
+		return true
+	})
+	return tokens
+}
+
 // Set sets the value for a given address.
+// Setting an earner and token pair that is already set is a collision, which is recorded
+// and resolved according to the distribution's DuplicatePolicy. A nil amount is an ErrInvalidAmount.
 func (d *Distribution) Set(address, token gethcommon.Address, amount *big.Int) error {
 	if d.Debug {
 		fmt.Printf("Distribution.Set: '%s' '%s' '%s'\n", address.String(), token.String(), amount.String())
 	}
-	allocatedTokens, found := d.data.Get(address)
-	if !found {
-		allocatedTokens = orderedmap.New[gethcommon.Address, *BigInt]()
-		d.data.Set(address, allocatedTokens)
-
+	if d.sealed {
+		return ErrDistributionSealed
+	}
+	if amount == nil {
+		return fmt.Errorf("%w - earner: %s, token: %s, amount: nil", ErrInvalidAmount, address.Hex(), token.Hex())
+	}
+	if err := validateAmount(amount); err != nil {
+		return fmt.Errorf("%w - earner: %s, token: %s", err, address.Hex(), token.Hex())
+	}
+	if _, found := d.store.tokenCount(address); !found {
 		// check if the address is added in order
-		prev := d.data.GetPair(address).Prev()
-		if prev != nil && prev.Key.Cmp(address) >= 0 {
-			// remove the address
-			d.data.Delete(address)
-			return fmt.Errorf("%w - prev: %s, attempt: %s", ErrAddressNotInOrder, prev.Key.Hex(), address.Hex())
+		if prev, found := d.store.lastEarner(); found && prev.Cmp(address) >= 0 {
+			return fmt.Errorf("%w - prev: %s, attempt: %s", ErrAddressNotInOrder, prev.Hex(), address.Hex())
+		}
+	}
+
+	if existing, found := d.store.get(address, token); found {
+		collision := Collision{Earner: address, Token: token, Existing: existing, Incoming: amount}
+		d.collisions = append(d.collisions, collision)
+		resolved, err := d.DuplicatePolicy.resolve(collision)
+		if err != nil {
+			return err
 		}
+		if err := validateAmount(orZero(resolved)); err != nil {
+			return fmt.Errorf("%w - earner: %s, token: %s", err, address.Hex(), token.Hex())
+		}
+		if err := d.store.setAmount(address, token, resolved); err != nil {
+			return err
+		}
+		d.invalidateIndices()
+		return nil
 	}
-	allocatedTokens.Set(token, &BigInt{Int: amount})
 
 	// check if the token is added in order
-	prev := allocatedTokens.GetPair(token).Prev()
-	if prev != nil && prev.Key.Cmp(token) >= 0 {
-		// remove the token
-		allocatedTokens.Delete(token)
-		return fmt.Errorf("%w - prev: %s, attempt: %s", ErrTokenNotInOrder, prev.Key.Hex(), token.Hex())
+	if prev, found := d.store.lastToken(address); found && prev.Cmp(token) >= 0 {
+		return fmt.Errorf("%w - prev: %s, attempt: %s", ErrTokenNotInOrder, prev.Hex(), token.Hex())
+	}
+	if err := d.store.appendLeaf(address, token, amount); err != nil {
+		return err
 	}
 
+	d.invalidateIndices()
 	return nil
 }
 
-// Get gets the value for a given address and whether it was in the distribution
-func (d *Distribution) Get(address, token gethcommon.Address) (*big.Int, bool) {
-	allocatedTokens, found := d.data.Get(address)
-	if !found {
-		return big.NewInt(0), false
+// Update changes the amount of an earner and token that is already in the distribution to a copy of amount.
+func (d *Distribution) Update(address, token gethcommon.Address, amount *big.Int) error {
+	if d.sealed {
+		return ErrDistributionSealed
 	}
-	amount, found := allocatedTokens.Get(token)
-	if !found {
-		return big.NewInt(0), false
+	if amount == nil {
+		return fmt.Errorf("%w - earner: %s, token: %s, amount: nil", ErrInvalidAmount, address.Hex(), token.Hex())
+	}
+	if err := validateAmount(amount); err != nil {
+		return fmt.Errorf("%w - earner: %s, token: %s", err, address.Hex(), token.Hex())
+	}
+	if _, found := d.store.tokenCount(address); !found {
+		return fmt.Errorf("%w: %s", ErrEarnerNotFound, address.Hex())
+	}
+	if _, found := d.store.get(address, token); !found {
+		return fmt.Errorf("%w - earner: %s, token: %s", ErrTokenNotFound, address.Hex(), token.Hex())
 	}
-	return amount.Int, true
-}
 
-func (d *Distribution) GetTokensForEarner(address gethcommon.Address) (*orderedmap.OrderedMap[gethcommon.Address, *BigInt], bool) {
-	return d.data.Get(address)
+	if err := d.store.setAmount(address, token, new(big.Int).Set(amount)); err != nil {
+		return err
+	}
+	d.invalidateIndices()
+	return nil
 }
 
-// Sets the index of the account in the distribution
-func (d *Distribution) setAccountIndex(address gethcommon.Address, index uint64) {
-	if d.accountIndices == nil {
-		d.accountIndices = make(map[gethcommon.Address]uint64)
+// DeleteEarner removes an earner and all of its tokens from the distribution.
+func (d *Distribution) DeleteEarner(address gethcommon.Address) error {
+	if d.sealed {
+		return ErrDistributionSealed
+	}
+	if !d.store.deleteEarner(address) {
+		return fmt.Errorf("%w: %s", ErrEarnerNotFound, address.Hex())
 	}
 
-	d.accountIndices[address] = index
+	d.invalidateIndices()
+	return nil
 }
 
-// Gets the index of the account in the distribution
-// Note that the indices must be set before calling this function
-func (d *Distribution) GetAccountIndex(address gethcommon.Address) (uint64, bool) {
-	if d.accountIndices == nil {
-		return 0, false
+// DeleteToken removes a token from an earner. The earner is removed when its last token is,
+// since an earner without tokens cannot be merklized.
+func (d *Distribution) DeleteToken(address, token gethcommon.Address) error {
+	if d.sealed {
+		return ErrDistributionSealed
+	}
+	if _, found := d.store.tokenCount(address); !found {
+		return fmt.Errorf("%w: %s", ErrEarnerNotFound, address.Hex())
 	}
-	index, found := d.accountIndices[address]
-	return index, found
+	if !d.store.deleteToken(address, token) {
+		return fmt.Errorf("%w - earner: %s, token: %s", ErrTokenNotFound, address.Hex(), token.Hex())
+	}
+
+	d.invalidateIndices()
+	return nil
 }
 
-// Sets the index of the token for a certain account in the distribution
-func (d *Distribution) setTokenIndex(address, token gethcommon.Address, index uint64) {
-	if d.tokenIndices == nil {
-		d.tokenIndices = make(map[gethcommon.Address]map[gethcommon.Address]uint64)
-	}
+// invalidateIndices clears the indices set by merklizing, since any change to the data
+// makes the trees and the indices into them stale.
+func (d *Distribution) invalidateIndices() {
+	d.indexed = false
+}
 
-	indices, found := d.tokenIndices[address]
+// setIndices sets the account and token indices from the tree order of the current data
+func (d *Distribution) setIndices() {
+	d.store.index()
+	d.indexed = true
+}
+
+// IsMerklized returns whether the account and token indices are set and match the current data.
+// It is false before the first merklization and after any change to the distribution.
+func (d *Distribution) IsMerklized() bool {
+	return d.indexed
+}
+
+// Get gets the value for a given address and whether it was in the distribution.
+// The value of a sealed distribution is a copy, so that it cannot be changed through it.
+func (d *Distribution) Get(address, token gethcommon.Address) (*big.Int, bool) {
+	amount, found := d.store.get(address, token)
 	if !found {
-		indices = make(map[gethcommon.Address]uint64)
-		d.tokenIndices[address] = indices
+		return big.NewInt(0), false
 	}
+	if d.sealed {
+		return copyBigInt(amount), true
+	}
+	return amount, true
+}
 
-	indices[token] = index
+// GetTokensForEarner returns the tokens of an earner and whether the earner is in the distribution.
+// Changing the returned map changes the distribution, unless it is compact or sealed, which return a copy.
+//
+// Deprecated: use ForEachToken and TokenCount, which do not expose the distribution's internal state.
+func (d *Distribution) GetTokensForEarner(address gethcommon.Address) (*orderedmap.OrderedMap[gethcommon.Address, *BigInt], bool) {
+	if store, ok := d.store.(*mapStore); ok && !d.sealed {
+		return store.data.Get(address)
+	}
+	if _, found := d.store.tokenCount(address); !found {
+		return nil, false
+	}
+	return d.copyTokens(address), true
 }
 
-// Gets the index of the token for a certain account in the distribution
+// Gets the index of the account in the distribution
 // Note that the indices must be set before calling this function
-func (d *Distribution) GetTokenIndex(address, token gethcommon.Address) (uint64, bool) {
-	if d.tokenIndices == nil {
+func (d *Distribution) GetAccountIndex(address gethcommon.Address) (uint64, bool) {
+	if !d.indexed {
 		return 0, false
 	}
+	return d.store.accountIndex(address)
+}
 
-	indices, found := d.tokenIndices[address]
-	if !found {
+// Gets the index of the token for a certain account in the distribution
+// Note that the indices must be set before calling this function
+func (d *Distribution) GetTokenIndex(address, token gethcommon.Address) (uint64, bool) {
+	if !d.indexed {
 		return 0, false
 	}
-
-	index, found := indices[token]
-	return index, found
+	return d.store.tokenIndex(address, token)
 }
 
 // GetStart returns the first pair in the distribution
-// used to iterate over the distribution
+// used to iterate over the distribution. A compact or sealed distribution returns a copy.
+//
+// Deprecated: use ForEachEarner, ForEachToken or ForEachLeaf, which do not expose the distribution's internal state.
 func (d *Distribution) GetStart() *orderedmap.Pair[gethcommon.Address, *orderedmap.OrderedMap[gethcommon.Address, *BigInt]] {
-	return d.data.Oldest()
+	if store, ok := d.store.(*mapStore); ok && !d.sealed {
+		return store.data.Oldest()
+	}
+	return d.copyData().Oldest()
 }
 
 // Merklizes the distribution and returns the account tree and the token trees.
+// See MerklizeAccounts for merklizing without retaining the token trees.
 func (d *Distribution) Merklize() (*merkletree.MerkleTree, map[gethcommon.Address]*merkletree.MerkleTree, error) {
-	// TODO: Do we need to have an option to merklize without all returning all the token trees and data?
-	tokenTrees := make(map[gethcommon.Address]*merkletree.MerkleTree, d.data.Len())
-
-	// todo: parallelize this
-	accountIndex := uint64(0)
-	accountLeafs := make([][]byte, 0)
-	for accountPair := d.data.Oldest(); accountPair != nil; accountPair = accountPair.Next() {
-		address := accountPair.Key
-		d.setAccountIndex(address, accountIndex)
-		// fetch the leafs for the tokens for this account
-		tokenIndex := uint64(0)
-		tokenLeafs := make([][]byte, 0)
-		for tokenPair := accountPair.Value.Oldest(); tokenPair != nil; tokenPair = tokenPair.Next() {
-			token := tokenPair.Key
-			amount := tokenPair.Value
-			d.setTokenIndex(address, token, tokenIndex)
-			tokenLeafs = append(tokenLeafs, EncodeTokenLeaf(token, amount.Int))
-			tokenIndex++
-		}
+	tokenTrees := make(map[gethcommon.Address]*merkletree.MerkleTree, d.store.earnerCount())
+	// see MerklizeParallel for the parallel version
+	accountTree, err := d.merklize(func(address gethcommon.Address, tokenTree *merkletree.MerkleTree) {
+		tokenTrees[address] = tokenTree
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return accountTree, tokenTrees, nil
+}
 
+// MerklizeAccounts merklizes the distribution and returns only the account tree.
+// Each token tree is discarded once its root is in the account tree, use GetTokenTree to rebuild
+// the token tree of an earner when it is needed for a proof.
+func (d *Distribution) MerklizeAccounts() (*merkletree.MerkleTree, error) {
+	return d.merklize(func(gethcommon.Address, *merkletree.MerkleTree) {})
+}
+
+// merklize sets the indices and builds the account tree, passing each token tree to keep
+func (d *Distribution) merklize(keep func(address gethcommon.Address, tokenTree *merkletree.MerkleTree)) (*merkletree.MerkleTree, error) {
+	if d.reportingView {
+		return nil, ErrReportingView
+	}
+	if err := checkLeafCount(d.store.earnerCount()); err != nil {
+		return nil, err
+	}
+	scheme := d.LeafScheme()
+	accountLeafs := make([][]byte, 0, d.store.earnerCount())
+	var err error
+	d.store.forEachEarner(func(address gethcommon.Address) bool {
 		// create a merkle tree for the tokens for this account
-		tokenTree, err := merkletree.NewTree(
-			merkletree.WithData(tokenLeafs),
-			merkletree.WithHashType(keccak256.New()),
-		)
+		var tokenTree *merkletree.MerkleTree
+		tokenTree, err = newTokenTree(scheme, d.store, address)
 		if err != nil {
-			return nil, nil, err
+			return false
 		}
-		tokenTrees[address] = tokenTree
+		keep(address, tokenTree)
 
 		// append the root to the list of account leafs
-		accountRoot := tokenTree.Root()
-		accountLeafs = append(accountLeafs, EncodeAccountLeaf(address, accountRoot))
-		accountIndex++
+		var accountLeaf []byte
+		accountLeaf, err = scheme.EncodeAccountLeaf(address, tokenTree.Root())
+		if err != nil {
+			return false
+		}
+		accountLeafs = append(accountLeafs, accountLeaf)
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	accountTree, err := merkletree.NewTree(
-		merkletree.WithData(accountLeafs),
-		merkletree.WithHashType(keccak256.New()),
-	)
+	accountTree, err := newKeccakTree(accountLeafs)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	d.setIndices()
+	return accountTree, nil
+}
 
-	return accountTree, tokenTrees, nil
+// GetTokenTree builds the token tree for an earner from the current distribution data
+func (d *Distribution) GetTokenTree(address gethcommon.Address) (*merkletree.MerkleTree, error) {
+	if _, found := d.store.tokenCount(address); !found {
+		return nil, fmt.Errorf("%w: %s", ErrEarnerNotFound, address.Hex())
+	}
+	return newTokenTree(d.LeafScheme(), d.store, address)
 }
 
-// encodeAccountLeaf encodes an account leaf for a token distribution.
-// precondition: accountRoot must be 32 bytes
+// encodeTokenLeafs encodes the token leafs of an account, in tree order
+func encodeTokenLeafs(scheme LeafScheme, store leafStore, address gethcommon.Address) ([][]byte, error) {
+	tokenCount, _ := store.tokenCount(address)
+	if err := checkLeafCount(tokenCount); err != nil {
+		return nil, err
+	}
+	tokenLeafs := make([][]byte, 0, tokenCount)
+	var err error
+	store.forEachToken(address, func(token gethcommon.Address, amount *big.Int) bool {
+		var tokenLeaf []byte
+		tokenLeaf, err = scheme.EncodeTokenLeaf(token, amount)
+		tokenLeafs = append(tokenLeafs, tokenLeaf)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokenLeafs, nil
+}
+
+// newTokenTree creates the token tree of an account
+func newTokenTree(scheme LeafScheme, store leafStore, address gethcommon.Address) (*merkletree.MerkleTree, error) {
+	tokenLeafs, err := encodeTokenLeafs(scheme, store, address)
+	if err != nil {
+		return nil, err
+	}
+	return newKeccakTree(tokenLeafs)
+}
+
+// EncodeAccountLeaf encodes an account leaf for a token distribution with LeafSchemeV1.
+// precondition: accountRoot must be 32 bytes, see EncodeAccountLeafChecked
 func EncodeAccountLeaf(account gethcommon.Address, accountRoot []byte) []byte {
 	// (EARNER_LEAF_SALT || account || accountRoot)
 	return append(EARNER_LEAF_SALT, append(account.Bytes(), accountRoot[:]...)...)
 }
 
-// encodeTokenLeaf encodes a token leaf for a token distribution.
+// EncodeAccountLeafChecked is EncodeAccountLeaf, returning an error if accountRoot is not 32 bytes
+func EncodeAccountLeafChecked(account gethcommon.Address, accountRoot []byte) ([]byte, error) {
+	if len(accountRoot) != 32 {
+		return nil, fmt.Errorf("%w - earner: %s, length: %d", ErrInvalidAccountRoot, account.Hex(), len(accountRoot))
+	}
+	return EncodeAccountLeaf(account, accountRoot), nil
+}
+
+// EncodeTokenLeaf encodes a token leaf for a token distribution with LeafSchemeV1.
+// precondition: amount must be a uint256, see EncodeTokenLeafChecked
 func EncodeTokenLeaf(token gethcommon.Address, amount *big.Int) []byte {
-	// todo: handle this better
 	amountU256, _ := uint256.FromBig(amount)
 	amountBytes := amountU256.Bytes32()
 	// (TOKEN_LEAF_SALT || token || amount)
 	return append(TOKEN_LEAF_SALT, append(token.Bytes(), amountBytes[:]...)...)
 }
+
+// EncodeTokenLeafChecked is EncodeTokenLeaf, returning an error if amount is nil, negative or does not fit in a uint256
+func EncodeTokenLeafChecked(token gethcommon.Address, amount *big.Int) ([]byte, error) {
+	if amount == nil {
+		return nil, fmt.Errorf("%w - token: %s, amount: nil", ErrInvalidAmount, token.Hex())
+	}
+	if err := validateAmount(amount); err != nil {
+		return nil, fmt.Errorf("%w - token: %s", err, token.Hex())
+	}
+	return EncodeTokenLeaf(token, amount), nil
+}
+
+// validateAmount checks that amount fits in the uint256 of a token leaf
+func validateAmount(amount *big.Int) error {
+	if amount.Sign() < 0 || amount.BitLen() > 256 {
+		return fmt.Errorf("%w, got %s", ErrInvalidAmount, amount.String())
+	}
+	return nil
+}
+
+// checkLeafCount checks that n leafs can be addressed by the RewardsCoordinator's uint32 indices
+func checkLeafCount(n int) error {
+	if uint64(n) > maxLeafs {
+		return fmt.Errorf("%w - %d leafs, max %d", ErrTooManyLeafs, n, uint64(maxLeafs))
+	}
+	return nil
+}
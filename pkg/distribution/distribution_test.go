@@ -1,12 +1,16 @@
 package distribution_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
 	"math/big"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
@@ -72,10 +76,10 @@ func FuzzSetAndGet(f *testing.F) {
 func TestSetNilAmount(t *testing.T) {
 	d := distribution.NewDistribution()
 	err := d.Set(common.Address{}, common.Address{}, nil)
-	assert.NoError(t, err)
+	assert.ErrorIs(t, err, distribution.ErrInvalidAmount)
 
 	_, found := d.Get(common.Address{}, common.Address{})
-	assert.True(t, found)
+	assert.False(t, found)
 }
 
 func TestSetAddressesInNonAlphabeticalOrder(t *testing.T) {
@@ -142,6 +146,72 @@ func TestEncodeTokenLeaf(t *testing.T) {
 	}
 }
 
+func TestEncodeTokenLeafChecked(t *testing.T) {
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	leaf, err := distribution.EncodeTokenLeafChecked(tests.TestTokens[0], maxUint256)
+	assert.NoError(t, err)
+	assert.Equal(t, distribution.EncodeTokenLeaf(tests.TestTokens[0], maxUint256), leaf)
+
+	for _, amount := range []*big.Int{nil, big.NewInt(-1), new(big.Int).Add(maxUint256, big.NewInt(1))} {
+		_, err := distribution.EncodeTokenLeafChecked(tests.TestTokens[0], amount)
+		assert.ErrorIs(t, err, distribution.ErrInvalidAmount)
+	}
+}
+
+func TestEncodeAccountLeafChecked(t *testing.T) {
+	testRoot, _ := hex.DecodeString(tests.TestRootsString[0])
+	leaf, err := distribution.EncodeAccountLeafChecked(tests.TestAddresses[0], testRoot)
+	assert.NoError(t, err)
+	assert.Equal(t, distribution.EncodeAccountLeaf(tests.TestAddresses[0], testRoot), leaf)
+
+	_, err = distribution.EncodeAccountLeafChecked(tests.TestAddresses[0], testRoot[:31])
+	assert.ErrorIs(t, err, distribution.ErrInvalidAccountRoot)
+}
+
+func TestSetOutOfRangeAmount(t *testing.T) {
+	d := distribution.NewDistribution()
+
+	err := d.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(-1))
+	assert.ErrorIs(t, err, distribution.ErrInvalidAmount)
+
+	err = d.Set(tests.TestAddresses[0], tests.TestTokens[0], new(big.Int).Lsh(big.NewInt(1), 256))
+	assert.ErrorIs(t, err, distribution.ErrInvalidAmount)
+
+	_, found := d.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.False(t, found)
+
+	// summing duplicates must not overflow either
+	d.DuplicatePolicy = distribution.DuplicatePolicySum
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	assert.NoError(t, d.Set(tests.TestAddresses[0], tests.TestTokens[0], maxUint256))
+	err = d.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1))
+	assert.ErrorIs(t, err, distribution.ErrInvalidAmount)
+}
+
+func TestLoadLinesOutOfRangeAmount(t *testing.T) {
+	lines := []*distribution.EarnerLine{
+		{Earner: tests.TestAddresses[0].Hex(), Token: tests.TestTokens[0].Hex(), CumulativeAmount: "-5"},
+		{Earner: tests.TestAddresses[1].Hex(), Token: tests.TestTokens[0].Hex(), CumulativeAmount: new(big.Int).Lsh(big.NewInt(1), 256).String()},
+	}
+
+	err := distribution.NewDistribution().LoadLines(lines)
+	assert.ErrorIs(t, err, distribution.ErrInvalidAmount)
+
+	var invalidErr *distribution.InvalidLinesError
+	assert.ErrorAs(t, err, &invalidErr)
+	assert.Len(t, invalidErr.Lines, 2)
+}
+
+func TestMerklizeNilAmount(t *testing.T) {
+	d := distribution.NewDistribution()
+	assert.ErrorIs(t, d.Set(tests.TestAddresses[0], tests.TestTokens[0], nil), distribution.ErrInvalidAmount)
+
+	// nothing was stored, so merklizing fails on the empty tree rather than on the amount
+	_, _, err := d.Merklize()
+	assert.ErrorIs(t, err, distribution.ErrEmptyTree)
+}
+
 func TestGetAccountIndexBeforeMerklization(t *testing.T) {
 	d := GetTestDistribution()
 
@@ -197,6 +267,213 @@ func TestMerklize(t *testing.T) {
 	}
 }
 
+func TestMerklizeParallel(t *testing.T) {
+	d, err := distribution.NewDistributionFromReader(strings.NewReader(getFullTestEarnerLines()))
+	assert.Nil(t, err)
+
+	accountTree, tokenTrees, err := d.Merklize()
+	assert.Nil(t, err)
+
+	for _, workers := range []int{0, 1, 4} {
+		progressCalls := 0
+		lastDone := 0
+		parallelAccountTree, parallelTokenTrees, err := d.MerklizeParallel(context.Background(), workers, func(done, total int) {
+			progressCalls++
+			lastDone = done
+			assert.Equal(t, len(tokenTrees), total)
+		})
+		assert.Nil(t, err)
+
+		assert.Equal(t, accountTree.Root(), parallelAccountTree.Root())
+		assert.Equal(t, accountTree.Nodes, parallelAccountTree.Nodes)
+		assert.Len(t, parallelTokenTrees, len(tokenTrees))
+		for address, tokenTree := range tokenTrees {
+			assert.Equal(t, tokenTree.Root(), parallelTokenTrees[address].Root())
+		}
+		assert.Equal(t, len(tokenTrees), progressCalls)
+		assert.Equal(t, len(tokenTrees), lastDone)
+	}
+}
+
+func TestMerklizeParallelCancelled(t *testing.T) {
+	d := GetTestDistribution()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := d.MerklizeParallel(ctx, 2, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+var errFailingLeafScheme = errors.New("failing leaf scheme")
+
+// failingLeafScheme is LeafSchemeV1, failing to encode the zero address token and counting the token leafs encoded
+type failingLeafScheme struct {
+	encoded *atomic.Int64
+}
+
+func (failingLeafScheme) Version() uint16 {
+	return 98
+}
+
+func (failingLeafScheme) EncodeAccountLeaf(earner common.Address, tokenRoot []byte) ([]byte, error) {
+	return distribution.LeafSchemeV1.EncodeAccountLeaf(earner, tokenRoot)
+}
+
+func (failingLeafScheme) DecodeAccountLeaf(leaf []byte) (common.Address, []byte, error) {
+	return distribution.LeafSchemeV1.DecodeAccountLeaf(leaf)
+}
+
+func (s failingLeafScheme) EncodeTokenLeaf(token common.Address, amount *big.Int) ([]byte, error) {
+	s.encoded.Add(1)
+	if token == (common.Address{}) {
+		return nil, errFailingLeafScheme
+	}
+	return distribution.LeafSchemeV1.EncodeTokenLeaf(token, amount)
+}
+
+func TestMerklizeParallelStopsAfterError(t *testing.T) {
+	scheme := failingLeafScheme{encoded: &atomic.Int64{}}
+	d := distribution.NewDistributionWithLeafScheme(scheme)
+	// the first job fails
+	assert.Nil(t, d.Set(common.BigToAddress(big.NewInt(1)), common.Address{}, big.NewInt(1)))
+	for i := 2; i <= 1000; i++ {
+		assert.Nil(t, d.Set(common.BigToAddress(big.NewInt(int64(i))), tests.TestTokens[0], big.NewInt(1)))
+	}
+
+	_, _, err := d.MerklizeParallel(context.Background(), 1, nil)
+	assert.ErrorIs(t, err, errFailingLeafScheme)
+	// at most the jobs already handed to the worker are built after the failure
+	assert.Less(t, scheme.encoded.Load(), int64(10))
+}
+
+func TestMerklizeAccounts(t *testing.T) {
+	d := GetTestDistribution()
+
+	accountTree, tokenTrees, err := d.Merklize()
+	assert.Nil(t, err)
+
+	lightAccountTree, err := GetTestDistribution().MerklizeAccounts()
+	assert.Nil(t, err)
+	assert.Equal(t, accountTree.Nodes, lightAccountTree.Nodes)
+
+	for address, tokenTree := range tokenTrees {
+		rebuilt, err := d.GetTokenTree(address)
+		assert.Nil(t, err)
+		assert.Equal(t, tokenTree.Nodes, rebuilt.Nodes)
+	}
+
+	_, err = d.GetTokenTree(tests.TestTokens[0])
+	assert.ErrorIs(t, err, distribution.ErrEarnerNotFound)
+}
+
+func TestUpdate(t *testing.T) {
+	d := GetTestDistribution()
+	_, _, err := d.Merklize()
+	assert.Nil(t, err)
+	assert.True(t, d.IsMerklized())
+
+	assert.Nil(t, d.Update(tests.TestAddresses[1], tests.TestTokens[2], big.NewInt(100)))
+	amount, found := d.Get(tests.TestAddresses[1], tests.TestTokens[2])
+	assert.True(t, found)
+	assert.Equal(t, big.NewInt(100), amount)
+
+	// the indices are stale until the distribution is merklized again
+	assert.False(t, d.IsMerklized())
+	_, found = d.GetAccountIndex(tests.TestAddresses[1])
+	assert.False(t, found)
+	_, found = d.GetTokenIndex(tests.TestAddresses[1], tests.TestTokens[2])
+	assert.False(t, found)
+
+	_, _, err = d.Merklize()
+	assert.Nil(t, err)
+	index, found := d.GetTokenIndex(tests.TestAddresses[1], tests.TestTokens[2])
+	assert.True(t, found)
+	assert.Equal(t, uint64(2), index)
+
+	assert.ErrorIs(t, d.Update(tests.TestAddresses[4], tests.TestTokens[4], big.NewInt(1)), distribution.ErrTokenNotFound)
+	assert.ErrorIs(t, d.Update(tests.TestTokens[0], tests.TestTokens[0], big.NewInt(1)), distribution.ErrEarnerNotFound)
+	assert.ErrorIs(t, d.Update(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(-1)), distribution.ErrInvalidAmount)
+	assert.ErrorIs(t, d.Update(tests.TestAddresses[0], tests.TestTokens[0], nil), distribution.ErrInvalidAmount)
+
+	// the caller's amount is copied
+	updated := big.NewInt(7)
+	assert.Nil(t, d.Update(tests.TestAddresses[0], tests.TestTokens[0], updated))
+	updated.SetInt64(8)
+	amount, _ = d.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.Equal(t, big.NewInt(7), amount)
+	_, _, err = d.Merklize()
+	assert.Nil(t, err)
+}
+
+func TestDelete(t *testing.T) {
+	d := GetTestDistribution()
+	_, _, err := d.Merklize()
+	assert.Nil(t, err)
+
+	assert.Nil(t, d.DeleteToken(tests.TestAddresses[0], tests.TestTokens[1]))
+	assert.False(t, d.IsMerklized())
+	_, found := d.Get(tests.TestAddresses[0], tests.TestTokens[1])
+	assert.False(t, found)
+
+	assert.Nil(t, d.DeleteEarner(tests.TestAddresses[2]))
+	assert.Equal(t, 0, d.TokenCount(tests.TestAddresses[2]))
+
+	// deleting the only token of an earner deletes the earner
+	assert.Nil(t, d.DeleteToken(tests.TestAddresses[4], tests.TestTokens[0]))
+	assert.Equal(t, 0, d.TokenCount(tests.TestAddresses[4]))
+	assert.Equal(t, 3, d.EarnerCount())
+
+	assert.ErrorIs(t, d.DeleteEarner(tests.TestAddresses[2]), distribution.ErrEarnerNotFound)
+	assert.ErrorIs(t, d.DeleteToken(tests.TestAddresses[0], tests.TestTokens[1]), distribution.ErrTokenNotFound)
+
+	// the remaining indices are contiguous and in order after merklizing again
+	_, _, err = d.Merklize()
+	assert.Nil(t, err)
+	index, found := d.GetAccountIndex(tests.TestAddresses[3])
+	assert.True(t, found)
+	assert.Equal(t, uint64(2), index)
+	index, found = d.GetTokenIndex(tests.TestAddresses[0], tests.TestTokens[2])
+	assert.True(t, found)
+	assert.Equal(t, uint64(1), index)
+
+	// ordering is still enforced after deleting
+	assert.ErrorIs(t, d.Set(tests.TestAddresses[2], tests.TestTokens[0], big.NewInt(1)), distribution.ErrAddressNotInOrder)
+}
+
+func TestUpdateAndDeleteSealed(t *testing.T) {
+	builder := distribution.NewDistributionBuilder(distribution.DuplicatePolicyReject)
+	assert.Nil(t, builder.Add(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1)))
+	d, err := builder.Build()
+	assert.Nil(t, err)
+
+	assert.ErrorIs(t, d.Update(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(2)), distribution.ErrDistributionSealed)
+	assert.ErrorIs(t, d.DeleteToken(tests.TestAddresses[0], tests.TestTokens[0]), distribution.ErrDistributionSealed)
+	assert.ErrorIs(t, d.DeleteEarner(tests.TestAddresses[0]), distribution.ErrDistributionSealed)
+}
+
+func TestSealedReturnsCopies(t *testing.T) {
+	builder := distribution.NewDistributionBuilder(distribution.DuplicatePolicyReject)
+	assert.Nil(t, builder.Add(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1)))
+	d, err := builder.Build()
+	assert.Nil(t, err)
+
+	amount, found := d.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.True(t, found)
+	amount.SetInt64(2)
+
+	tokens, found := d.GetTokensForEarner(tests.TestAddresses[0])
+	assert.True(t, found)
+	tokens.Set(tests.TestTokens[1], &distribution.BigInt{Int: big.NewInt(3)})
+	tokens.Oldest().Value.Int.SetInt64(4)
+
+	d.GetStart().Value.Oldest().Value.Int.SetInt64(5)
+
+	amount, _ = d.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.Equal(t, big.NewInt(1), amount)
+	assert.Equal(t, 1, d.LeafCount())
+}
+
 func TestNewDistributionWithData(t *testing.T) {
 	distro, err := distribution.NewDistributionWithData(tests.TestJsonDistribution)
 	assert.Nil(t, err)
@@ -209,6 +486,26 @@ func TestNewDistributionWithData(t *testing.T) {
 	assert.Len(t, tokens[addr].Data, 1)
 }
 
+func TestNewDistributionWithDataOutOfOrder(t *testing.T) {
+	d, err := distribution.NewDistributionWithData([]byte(fmt.Sprintf(`{"%s":{"%s":"3","%s":"2"},"%s":{"%s":"1"}}`,
+		tests.TestAddresses[1].Hex(), tests.TestTokens[1].Hex(), tests.TestTokens[0].Hex(),
+		tests.TestAddresses[0].Hex(), tests.TestTokens[0].Hex())))
+	assert.Nil(t, err)
+
+	// the keys are sorted into tree order as they are loaded
+	expected := distribution.NewDistribution()
+	assert.Nil(t, expected.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1)))
+	assert.Nil(t, expected.Set(tests.TestAddresses[1], tests.TestTokens[0], big.NewInt(2)))
+	assert.Nil(t, expected.Set(tests.TestAddresses[1], tests.TestTokens[1], big.NewInt(3)))
+	assert.True(t, distribution.DiffDistributions(expected, d).IsEmpty())
+
+	accountTree, _, err := d.Merklize()
+	assert.Nil(t, err)
+	expectedAccountTree, _, err := expected.Merklize()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedAccountTree.Root(), accountTree.Root())
+}
+
 func TestNewDistributionWithClaimDataLines(t *testing.T) {
 	allLines := getFullTestEarnerLines()
 	earnerLines := strings.Split(allLines, "\n")
@@ -232,6 +529,130 @@ func TestNewDistributionWithClaimDataLines(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestLoadLinesSortsByAddressBytes(t *testing.T) {
+	lines := []*distribution.EarnerLine{
+		// checksummed, sorts before the lowercase earners as a string but after them as bytes
+		{Earner: "0xDB5117dd6769e1a3442dd19f6bf89e2b8c2e011b", Token: tests.TestTokens[1].Hex(), CumulativeAmount: "3"},
+		{Earner: "0x55ccb6ec92959052b9f1bf35b2cef438cf626aa5", Token: "dd78fcf0c0814218f9e8863142b904d7a04b7ae5", CumulativeAmount: "2"},
+		{Earner: "0x55ccb6ec92959052b9f1bf35b2cef438cf626aa5", Token: "0x257601e63cc667ba6ad3561eb197f0edad4f96f7", CumulativeAmount: "1"},
+	}
+
+	d := distribution.NewDistribution()
+	assert.Nil(t, d.LoadLines(lines))
+
+	amount, found := d.Get(tests.TestAddresses[3], tests.TestTokens[1])
+	assert.True(t, found)
+	assert.Equal(t, big.NewInt(3), amount)
+
+	amount, found = d.Get(tests.TestAddresses[1], tests.TestTokens[4])
+	assert.True(t, found)
+	assert.Equal(t, big.NewInt(2), amount)
+}
+
+func TestLoadLinesReportsInvalidLines(t *testing.T) {
+	lines := []*distribution.EarnerLine{
+		{Earner: tests.TestAddresses[0].Hex(), Token: tests.TestTokens[0].Hex(), CumulativeAmount: "1"},
+		{Earner: "0x1234", Token: tests.TestTokens[0].Hex(), CumulativeAmount: "1"},
+		{Earner: tests.TestAddresses[1].Hex(), Token: "0xzz7601e63cc667ba6ad3561eb197f0edad4f96f7", CumulativeAmount: "1"},
+		{Earner: tests.TestAddresses[2].Hex(), Token: tests.TestTokens[0].Hex(), CumulativeAmount: "1.5"},
+	}
+
+	d := distribution.NewDistribution()
+	err := d.LoadLines(lines)
+	assert.ErrorIs(t, err, distribution.ErrInvalidAddress)
+
+	var invalidErr *distribution.InvalidLinesError
+	assert.ErrorAs(t, err, &invalidErr)
+	assert.Len(t, invalidErr.Lines, 3)
+	assert.Equal(t, 2, invalidErr.Lines[0].Line)
+	assert.Equal(t, 3, invalidErr.Lines[1].Line)
+	assert.Equal(t, 4, invalidErr.Lines[2].Line)
+
+	// nothing should have been loaded
+	_, found := d.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.False(t, found)
+}
+
+func TestSetDuplicatePolicies(t *testing.T) {
+	earner := tests.TestAddresses[0]
+	token := tests.TestTokens[0]
+
+	testCases := []struct {
+		policy   distribution.DuplicatePolicy
+		expected *big.Int
+		err      error
+	}{
+		{distribution.DuplicatePolicyReject, big.NewInt(5), distribution.ErrDuplicateLeaf},
+		{distribution.DuplicatePolicySum, big.NewInt(8), nil},
+		{distribution.DuplicatePolicyKeepMax, big.NewInt(5), nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			d := distribution.NewDistribution()
+			d.DuplicatePolicy = tc.policy
+
+			assert.NoError(t, d.Set(earner, token, big.NewInt(5)))
+			assert.NoError(t, d.Set(earner, tests.TestTokens[1], big.NewInt(1)))
+			err := d.Set(earner, token, big.NewInt(3))
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			amount, found := d.Get(earner, token)
+			assert.True(t, found)
+			assert.Equal(t, tc.expected, amount)
+
+			collisions := d.Collisions()
+			assert.Len(t, collisions, 1)
+			assert.Equal(t, earner, collisions[0].Earner)
+			assert.Equal(t, token, collisions[0].Token)
+			assert.Equal(t, big.NewInt(5), collisions[0].Existing)
+			assert.Equal(t, big.NewInt(3), collisions[0].Incoming)
+		})
+	}
+}
+
+func TestLoadLinesDuplicates(t *testing.T) {
+	lines := func() []*distribution.EarnerLine {
+		return []*distribution.EarnerLine{
+			{Earner: tests.TestAddresses[0].Hex(), Token: tests.TestTokens[0].Hex(), CumulativeAmount: "1"},
+			{Earner: tests.TestAddresses[1].Hex(), Token: tests.TestTokens[0].Hex(), CumulativeAmount: "4"},
+			{Earner: tests.TestAddresses[0].Hex(), Token: tests.TestTokens[0].Hex(), CumulativeAmount: "2"},
+			{Earner: tests.TestAddresses[1].Hex(), Token: tests.TestTokens[0].Hex(), CumulativeAmount: "3"},
+		}
+	}
+
+	d := distribution.NewDistribution()
+	err := d.LoadLines(lines())
+	assert.ErrorIs(t, err, distribution.ErrDuplicateLeaf)
+	assert.Len(t, d.Collisions(), 2)
+	// a failed load sets nothing
+	assert.Equal(t, 0, d.LeafCount())
+
+	d = distribution.NewDistribution()
+	d.DuplicatePolicy = distribution.DuplicatePolicySum
+	assert.NoError(t, d.LoadLines(lines()))
+	amount, _ := d.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.Equal(t, big.NewInt(3), amount)
+	amount, _ = d.Get(tests.TestAddresses[1], tests.TestTokens[0])
+	assert.Equal(t, big.NewInt(7), amount)
+	assert.Len(t, d.Collisions(), 2)
+
+	// a duplicate of an existing leaf leaves the distribution unchanged
+	d = distribution.NewDistribution()
+	assert.Nil(t, d.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1)))
+	err = d.LoadLines([]*distribution.EarnerLine{
+		{Earner: tests.TestAddresses[0].Hex(), Token: tests.TestTokens[1].Hex(), CumulativeAmount: "2"},
+		{Earner: tests.TestAddresses[0].Hex(), Token: tests.TestTokens[0].Hex(), CumulativeAmount: "3"},
+	})
+	assert.ErrorIs(t, err, distribution.ErrDuplicateLeaf)
+	assert.Equal(t, 1, d.LeafCount())
+	amount, _ = d.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.Equal(t, big.NewInt(1), amount)
+}
+
 func TestDistributionLineUnMarshal(t *testing.T) {
 	line := `{"earner":"0xd37f737629e0ddad7fc8adc7247d2e79c0296c35","token":"0xe1b7a1249c71b538cc183b0080ffc3efd02bffb9","snapshot":1716681600000,"cumulative_amount":"2.690822691e+27"}`
 
@@ -241,6 +662,45 @@ func TestDistributionLineUnMarshal(t *testing.T) {
 	fmt.Printf("Earner line: %+v\n", earner)
 }
 
+func TestNewDistributionFromReader(t *testing.T) {
+	distro, err := distribution.NewDistributionFromReader(strings.NewReader(getFullTestEarnerLines()))
+	assert.Nil(t, err)
+
+	earners := make([]*distribution.EarnerLine, 0)
+	for _, e := range strings.Split(getFullTestEarnerLines(), "\n") {
+		if e == "" {
+			continue
+		}
+		earner := &distribution.EarnerLine{}
+		assert.Nil(t, json.Unmarshal([]byte(e), earner))
+		earners = append(earners, earner)
+	}
+	expected := distribution.NewDistribution()
+	assert.Nil(t, expected.LoadLines(earners))
+
+	accountTree, _, err := distro.Merklize()
+	assert.Nil(t, err)
+	expectedAccountTree, _, err := expected.Merklize()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedAccountTree.Root(), accountTree.Root())
+}
+
+func TestNewDistributionFromReaderLineError(t *testing.T) {
+	input := `{"earner":"0xd37f737629e0ddad7fc8adc7247d2e79c0296c35","token":"0xe1b7a1249c71b538cc183b0080ffc3efd02bffb9","cumulative_amount":"1"}
+
+{"earner":"0xd37f737629e0ddad7fc8adc7247d2e79c0296c35","token":"0x94373a4919b3240d86ea41593d5eba789fef3848","cumulative_amount":"abc"}
+`
+	_, err := distribution.NewDistributionFromReader(strings.NewReader(input))
+
+	var lineErr *distribution.LineError
+	assert.ErrorAs(t, err, &lineErr)
+	assert.Equal(t, 3, lineErr.Line)
+
+	_, err = distribution.NewDistributionFromReader(strings.NewReader("{not json}\n"))
+	assert.ErrorAs(t, err, &lineErr)
+	assert.Equal(t, 1, lineErr.Line)
+}
+
 func getFullTestEarnerLines() string {
 	return `{"earner":"0xce50089021676aa2cbac4cc72a2aa655b495bc73","token":"0x94373a4919b3240d86ea41593d5eba789fef3848","snapshot":1716681600000,"cumulative_amount":"6102895758009265"}
 {"earner":"0xc78b64ab536792da7b8b913f09b2954ea0b9025b","token":"0x94373a4919b3240d86ea41593d5eba789fef3848","snapshot":1716681600000,"cumulative_amount":"6102895758009265"}
@@ -847,3 +1307,100 @@ func getFullTestEarnerLines() string {
 {"earner":"0xb889189803685c04a654b8c69ea494c7265598bf","token":"0xa2f77c34ec2468b902863992630b7d83e674e49a","snapshot":1716681600000,"cumulative_amount":"118587155005713"}
 `
 }
+
+func TestMarshalJSONQuoteAmounts(t *testing.T) {
+	d := distribution.NewDistribution()
+	large, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	assert.Nil(t, d.Set(tests.TestAddresses[0], tests.TestTokens[0], large))
+
+	bare, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Contains(t, string(bare), `:123456789012345678901234567890}`)
+
+	d.QuoteAmounts = true
+	quoted, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Contains(t, string(quoted), `:"123456789012345678901234567890"}`)
+
+	// both forms load to the same distribution
+	for _, data := range [][]byte{bare, quoted} {
+		loaded, err := distribution.NewDistributionWithData(data)
+		assert.Nil(t, err)
+		amount, found := loaded.Get(tests.TestAddresses[0], tests.TestTokens[0])
+		assert.True(t, found)
+		assert.Equal(t, large, amount)
+	}
+}
+
+func TestBigIntUnmarshalJSON(t *testing.T) {
+	var b distribution.BigInt
+	assert.Nil(t, json.Unmarshal([]byte(`"42"`), &b))
+	assert.Equal(t, big.NewInt(42), b.Int)
+	assert.Nil(t, json.Unmarshal([]byte(`43`), &b))
+	assert.Equal(t, big.NewInt(43), b.Int)
+
+	assert.NotNil(t, json.Unmarshal([]byte(`""`), &b))
+	assert.NotNil(t, json.Unmarshal([]byte(`"1.5"`), &b))
+	assert.NotNil(t, json.Unmarshal([]byte(`"abc"`), &b))
+}
+
+func TestEarnerLineUnmarshalNumbers(t *testing.T) {
+	large := "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+	for _, amount := range []string{large, `"` + large + `"`} {
+		line := &distribution.EarnerLine{}
+		err := json.Unmarshal([]byte(`{"earner":"0x2222aac0c980cc029624b7ff55b88bc6f63c538f","token":"0x94373a4919b3240d86ea41593d5eba789fef3848","cumulative_amount":`+amount+`}`), line)
+		assert.Nil(t, err)
+
+		parsed, err := line.CumulativeAmountBigInt()
+		assert.Nil(t, err)
+		assert.Equal(t, large, parsed.String())
+	}
+
+	// fraction and exponent forms are exact when every digit is written out
+	for amount, expected := range map[string]int64{"1.5e+1": 15, "1.500e+3": 1500, "15.00": 15, "1500e-2": 15, "1e0": 1} {
+		line := &distribution.EarnerLine{CumulativeAmount: amount}
+		parsed, err := line.CumulativeAmountBigInt()
+		assert.Nil(t, err, amount)
+		assert.Equal(t, big.NewInt(expected), parsed, amount)
+	}
+
+	// trailing digits implied by the exponent may have been rounded away, however small the value
+	for _, amount := range []string{"1.5e+3", "3.590287212e+15", "1.083011266e+19", "1e3"} {
+		line := &distribution.EarnerLine{CumulativeAmount: amount}
+		_, err := line.CumulativeAmountBigInt()
+		assert.ErrorIs(t, err, distribution.ErrLossyAmount, amount)
+	}
+
+	line := &distribution.EarnerLine{CumulativeAmount: "1.5"}
+	_, err := line.CumulativeAmountBigInt()
+	assert.ErrorIs(t, err, distribution.ErrInvalidAmount)
+
+	line = &distribution.EarnerLine{CumulativeAmount: "0x10"}
+	_, err = line.CumulativeAmountBigInt()
+	assert.NotNil(t, err)
+
+	err = json.Unmarshal([]byte(`{"cumulative_amount":true}`), line)
+	assert.NotNil(t, err)
+}
+
+func TestEarnerLineMetadata(t *testing.T) {
+	input := `{"earner":"0x2222aac0c980cc029624b7ff55b88bc6f63c538f","token":"0x94373a4919b3240d86ea41593d5eba789fef3848","_col2":1714780800000,"cumulative_amount":10830112660}`
+
+	line := &distribution.EarnerLine{}
+	assert.Nil(t, json.Unmarshal([]byte(input), line))
+	assert.Equal(t, "10830112660", line.CumulativeAmount)
+	assert.Equal(t, json.RawMessage("1714780800000"), line.Metadata["_col2"])
+
+	out, err := json.Marshal(line)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"earner":"0x2222aac0c980cc029624b7ff55b88bc6f63c538f","token":"0x94373a4919b3240d86ea41593d5eba789fef3848","cumulative_amount":"10830112660","_col2":1714780800000}`, string(out))
+}
+
+func TestNewDistributionFromReaderLossyAmounts(t *testing.T) {
+	_, err := distribution.NewDistributionFromReader(bytes.NewReader(tests.TestClaims))
+	assert.ErrorIs(t, err, distribution.ErrLossyAmount)
+
+	var invalidErr *distribution.InvalidLinesError
+	assert.ErrorAs(t, err, &invalidErr)
+	assert.Equal(t, 1, invalidErr.Lines[0].Line)
+}
@@ -0,0 +1,180 @@
+package distribution
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/wealdtech/go-merkletree/v2"
+)
+
+// TreeDumpVersion is the version of the JSON schema written by WriteTreeDump
+const TreeDumpVersion = 1
+
+var ErrUnsupportedTreeDumpVersion = errors.New("unsupported tree dump version")
+var ErrTreeDumpMismatch = errors.New("tree dump does not match its leafs")
+
+// TreeDump is every leaf and node of a merklized distribution, for deriving proofs without this library.
+//
+// Trees are keccak256 merkle trees padded to a power of two with 32 zero bytes as the leaf hash, the padding
+// is not the hash of anything. Layers go from the leaf hashes, including the padding, up to the root, so a
+// proof for leaf i is layers[k][(i>>k)^1] for every layer k below the root. Leafs are encoded with the
+// scheme LeafScheme identifies, see GetLeafScheme. All bytes are 0x prefixed hex and amounts are decimal
+// strings.
+type TreeDump struct {
+	Version           int               `json:"version"`
+	LeafScheme        uint16            `json:"leaf_scheme"`
+	Root              hexutil.Bytes     `json:"root"`
+	AccountTreeLayers [][]hexutil.Bytes `json:"account_tree_layers"`
+	Earners           []EarnerDump      `json:"earners"`
+}
+
+// EarnerDump is an earner's account leaf and token tree, Leaf is the leaf scheme's EncodeAccountLeaf(Earner, TokenRoot)
+type EarnerDump struct {
+	Earner          gethcommon.Address `json:"earner"`
+	Index           uint64             `json:"index"`
+	Leaf            hexutil.Bytes      `json:"leaf"`
+	LeafHash        hexutil.Bytes      `json:"leaf_hash"`
+	TokenRoot       hexutil.Bytes      `json:"token_root"`
+	TokenTreeLayers [][]hexutil.Bytes  `json:"token_tree_layers"`
+	Tokens          []TokenDump        `json:"tokens"`
+}
+
+// TokenDump is a token leaf, Leaf is the leaf scheme's EncodeTokenLeaf(Token, CumulativeAmount)
+type TokenDump struct {
+	Token            gethcommon.Address `json:"token"`
+	Index            uint64             `json:"index"`
+	CumulativeAmount string             `json:"cumulative_amount"`
+	Leaf             hexutil.Bytes      `json:"leaf"`
+	LeafHash         hexutil.Bytes      `json:"leaf_hash"`
+}
+
+// Dump lists every leaf and node of the distribution's trees
+func (m *MerklizedDistribution) Dump() (*TreeDump, error) {
+	accountTreeLayers := treeLayers(m.accountTree)
+	dump := &TreeDump{
+		Version:           TreeDumpVersion,
+		LeafScheme:        m.LeafScheme().Version(),
+		Root:              m.Root(),
+		AccountTreeLayers: accountTreeLayers,
+		Earners:           make([]EarnerDump, 0, m.EarnerCount()),
+	}
+
+	var err error
+	m.ForEachEarner(func(earner gethcommon.Address) bool {
+		var tokenTree *merkletree.MerkleTree
+		tokenTree, err = m.GetTokenTree(earner)
+		if err != nil {
+			return false
+		}
+		index := uint64(len(dump.Earners))
+		earnerDump := EarnerDump{
+			Earner:          earner,
+			Index:           index,
+			Leaf:            m.accountTree.Data[index],
+			LeafHash:        accountTreeLayers[0][index],
+			TokenRoot:       tokenTree.Root(),
+			TokenTreeLayers: treeLayers(tokenTree),
+			Tokens:          make([]TokenDump, 0, len(tokenTree.Data)),
+		}
+		m.ForEachToken(earner, func(token gethcommon.Address, amount *big.Int) bool {
+			tokenIndex := uint64(len(earnerDump.Tokens))
+			earnerDump.Tokens = append(earnerDump.Tokens, TokenDump{
+				Token:            token,
+				Index:            tokenIndex,
+				CumulativeAmount: orZero(amount).String(),
+				Leaf:             tokenTree.Data[tokenIndex],
+				LeafHash:         earnerDump.TokenTreeLayers[0][tokenIndex],
+			})
+			return true
+		})
+		dump.Earners = append(dump.Earners, earnerDump)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// WriteTreeDump writes the dump of a merklized distribution to w as JSON
+func WriteTreeDump(w io.Writer, m *MerklizedDistribution) error {
+	dump, err := m.Dump()
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(dump)
+}
+
+// ReadTreeDump reads a dump written by WriteTreeDump and checks it by rebuilding the trees from its
+// earners, tokens and amounts. It returns ErrTreeDumpMismatch if any leaf, node or the root differs.
+// The rebuilt distribution is returned alongside the dump for proof generation.
+func ReadTreeDump(r io.Reader) (*TreeDump, *MerklizedDistribution, error) {
+	dump := &TreeDump{}
+	if err := json.NewDecoder(r).Decode(dump); err != nil {
+		return nil, nil, err
+	}
+	if dump.Version != TreeDumpVersion {
+		return nil, nil, fmt.Errorf("%w: %d", ErrUnsupportedTreeDumpVersion, dump.Version)
+	}
+
+	scheme, err := GetLeafScheme(dump.LeafScheme)
+	if err != nil {
+		return nil, nil, err
+	}
+	distro := NewDistributionWithLeafScheme(scheme)
+	for _, earner := range dump.Earners {
+		for _, token := range earner.Tokens {
+			amount, ok := new(big.Int).SetString(token.CumulativeAmount, 10)
+			if !ok {
+				return nil, nil, fmt.Errorf("%w - earner: %s, token: %s, amount: '%s'", ErrInvalidAmount, earner.Earner.Hex(), token.Token.Hex(), token.CumulativeAmount)
+			}
+			if err := distro.Set(earner.Earner, token.Token, amount); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	merklized, err := distro.Freeze()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(merklized.Root(), dump.Root) {
+		return nil, nil, fmt.Errorf("%w: expected root %s, got %s", ErrTreeDumpMismatch, dump.Root, hexutil.Bytes(merklized.Root()))
+	}
+
+	rebuilt, err := merklized.Dump()
+	if err != nil {
+		return nil, nil, err
+	}
+	rebuilt.Version = dump.Version
+	expected, err := json.Marshal(rebuilt)
+	if err != nil {
+		return nil, nil, err
+	}
+	actual, err := json.Marshal(dump)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(expected, actual) {
+		return nil, nil, fmt.Errorf("%w: leafs or nodes differ from the rebuilt trees", ErrTreeDumpMismatch)
+	}
+	return dump, merklized, nil
+}
+
+// treeLayers splits the nodes of a tree into layers from the leaf hashes up to the root
+func treeLayers(tree *merkletree.MerkleTree) [][]hexutil.Bytes {
+	layers := make([][]hexutil.Bytes, 0)
+	for size := len(tree.Nodes) / 2; size >= 1; size /= 2 {
+		layer := make([]hexutil.Bytes, 0, size)
+		for _, node := range tree.Nodes[size : 2*size] {
+			layer = append(layer, node)
+		}
+		layers = append(layers, layer)
+	}
+	return layers
+}
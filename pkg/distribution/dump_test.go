@@ -0,0 +1,87 @@
+package distribution_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-merkletree/v2/keccak256"
+)
+
+func TestTreeDumpRoundTrip(t *testing.T) {
+	d := GetTestDistribution()
+	accountTree, tokenTrees, err := d.Merklize()
+	assert.Nil(t, err)
+	merklized, err := d.Freeze()
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, distribution.WriteTreeDump(&buf, merklized))
+
+	dump, rebuilt, err := distribution.ReadTreeDump(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, accountTree.Root(), []byte(dump.Root))
+	assert.Equal(t, accountTree.Root(), rebuilt.Root())
+
+	hash := keccak256.New()
+	layers := dump.AccountTreeLayers
+	assert.Equal(t, []byte(dump.Root), []byte(layers[len(layers)-1][0]))
+	assert.Len(t, dump.Earners, len(tests.TestAddresses))
+	for i, earner := range dump.Earners {
+		assert.Equal(t, tests.TestAddresses[i], earner.Earner)
+		assert.Equal(t, uint64(i), earner.Index)
+		assert.Equal(t, accountTree.Data[i], []byte(earner.Leaf))
+		assert.Equal(t, hash.Hash(earner.Leaf), []byte(earner.LeafHash))
+		assert.Equal(t, tokenTrees[earner.Earner].Root(), []byte(earner.TokenRoot))
+		for j, token := range earner.Tokens {
+			assert.Equal(t, tests.TestTokens[j], token.Token)
+			assert.Equal(t, hash.Hash(token.Leaf), []byte(token.LeafHash))
+			assert.Equal(t, []byte(token.LeafHash), []byte(earner.TokenTreeLayers[0][j]))
+		}
+	}
+}
+
+func TestReadTreeDumpMismatch(t *testing.T) {
+	merklized, err := GetTestDistribution().Freeze()
+	assert.Nil(t, err)
+	dump, err := merklized.Dump()
+	assert.Nil(t, err)
+
+	tamper := func(fn func(dump *distribution.TreeDump)) error {
+		var tampered distribution.TreeDump
+		data, err := json.Marshal(dump)
+		assert.Nil(t, err)
+		assert.Nil(t, json.Unmarshal(data, &tampered))
+		fn(&tampered)
+		data, err = json.Marshal(tampered)
+		assert.Nil(t, err)
+		_, _, err = distribution.ReadTreeDump(bytes.NewReader(data))
+		return err
+	}
+
+	assert.ErrorIs(t, tamper(func(d *distribution.TreeDump) { d.Earners[1].Tokens[0].CumulativeAmount = "1000" }), distribution.ErrTreeDumpMismatch)
+	assert.ErrorIs(t, tamper(func(d *distribution.TreeDump) { d.AccountTreeLayers[0][1][0] ^= 1 }), distribution.ErrTreeDumpMismatch)
+	assert.ErrorIs(t, tamper(func(d *distribution.TreeDump) { d.Earners[0].TokenTreeLayers[1][0][0] ^= 1 }), distribution.ErrTreeDumpMismatch)
+	assert.ErrorIs(t, tamper(func(d *distribution.TreeDump) { d.Version = 2 }), distribution.ErrUnsupportedTreeDumpVersion)
+	assert.ErrorIs(t, tamper(func(d *distribution.TreeDump) { d.Earners[0].Tokens[0].CumulativeAmount = "x" }), distribution.ErrInvalidAmount)
+	assert.Nil(t, tamper(func(d *distribution.TreeDump) {}))
+
+	assert.ErrorIs(t, tamper(func(d *distribution.TreeDump) { d.LeafScheme = 0 }), distribution.ErrUnsupportedLeafScheme)
+}
+
+func TestTreeDumpSingleLeaf(t *testing.T) {
+	d, err := distribution.NewDistributionWithData(tests.TestJsonDistribution)
+	assert.Nil(t, err)
+	merklized, err := d.Freeze()
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, distribution.WriteTreeDump(&buf, merklized))
+	dump, _, err := distribution.ReadTreeDump(&buf)
+	assert.Nil(t, err)
+	assert.Len(t, dump.AccountTreeLayers, 1)
+	assert.Equal(t, []byte(dump.Root), []byte(dump.AccountTreeLayers[0][0]))
+}
@@ -0,0 +1,76 @@
+package distribution
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+var ErrDuplicateLeaf = errors.New("duplicate earner and token")
+
+// DuplicatePolicy decides what Set does when an earner and token pair is set more than once
+type DuplicatePolicy int
+
+const (
+	// DuplicatePolicyReject fails the Set and keeps the existing amount. It is the default, which is a breaking change:
+	// Set used to overwrite the existing amount, so callers relying on that must drop duplicates themselves.
+	DuplicatePolicyReject DuplicatePolicy = iota
+	// DuplicatePolicySum adds the new amount to the existing amount
+	DuplicatePolicySum
+	// DuplicatePolicyKeepMax keeps the larger of the existing and new amounts
+	DuplicatePolicyKeepMax
+)
+
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case DuplicatePolicyReject:
+		return "reject"
+	case DuplicatePolicySum:
+		return "sum"
+	case DuplicatePolicyKeepMax:
+		return "keep-max"
+	default:
+		return fmt.Sprintf("DuplicatePolicy(%d)", int(p))
+	}
+}
+
+// Collision records an earner and token pair that was set more than once
+type Collision struct {
+	Earner   gethcommon.Address `json:"earner"`
+	Token    gethcommon.Address `json:"token"`
+	Existing *big.Int           `json:"existing"`
+	Incoming *big.Int           `json:"incoming"`
+}
+
+// resolve returns the amount to keep for a collision under the policy
+func (p DuplicatePolicy) resolve(c Collision) (*big.Int, error) {
+	switch p {
+	case DuplicatePolicyReject:
+		return nil, fmt.Errorf("%w - earner: %s, token: %s", ErrDuplicateLeaf, c.Earner.Hex(), c.Token.Hex())
+	case DuplicatePolicySum:
+		return new(big.Int).Add(orZero(c.Existing), orZero(c.Incoming)), nil
+	case DuplicatePolicyKeepMax:
+		if orZero(c.Incoming).Cmp(orZero(c.Existing)) > 0 {
+			return c.Incoming, nil
+		}
+		return c.Existing, nil
+	default:
+		return nil, fmt.Errorf("unknown duplicate policy: %s", p)
+	}
+}
+
+// Collisions returns every duplicate earner and token pair seen by Set, in the order they were seen
+func (d *Distribution) Collisions() []Collision {
+	collisions := make([]Collision, len(d.collisions))
+	copy(collisions, d.collisions)
+	return collisions
+}
+
+func orZero(amount *big.Int) *big.Int {
+	if amount == nil {
+		return new(big.Int)
+	}
+	return amount
+}
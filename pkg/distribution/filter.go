@@ -0,0 +1,59 @@
+package distribution
+
+import (
+	"errors"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+var ErrReportingView = errors.New("distribution is a reporting view and cannot be merklized")
+
+// Filter restricts a distribution to some earners and tokens. An empty list matches everything.
+type Filter struct {
+	Earners []gethcommon.Address
+	Tokens  []gethcommon.Address
+}
+
+// Filter copies the leafs matching the filter into a new distribution for reporting.
+// Earners left without tokens are dropped.
+//
+// The result is a reporting view: its root would differ from the published one, so it cannot be
+// merklized, frozen or written with WriteSnapshot. Its leafs can still be exported with MarshalJSON,
+// WriteNDJSON or WriteCSV. Use claimgen.GetProofsForReportingView to prove its leafs against the
+// original distribution.
+func (d *Distribution) Filter(filter Filter) *Distribution {
+	earners := addressSet(filter.Earners)
+	tokens := addressSet(filter.Tokens)
+
+	view := &Distribution{
+		store:         d.store.empty(),
+		reportingView: true,
+		leafScheme:    d.leafScheme,
+	}
+	d.ForEachLeaf(func(earner, token gethcommon.Address, amount *big.Int) bool {
+		if (earners == nil || earners[earner]) && (tokens == nil || tokens[token]) {
+			// the leafs are copied in order from a valid distribution, so this cannot fail
+			_ = view.store.appendLeaf(earner, token, amount)
+		}
+		return true
+	})
+	return view
+}
+
+// IsReportingView returns whether the distribution was created by Filter
+func (d *Distribution) IsReportingView() bool {
+	return d.reportingView
+}
+
+// addressSet returns nil for an empty list, meaning every address matches
+func addressSet(addresses []gethcommon.Address) map[gethcommon.Address]bool {
+	if len(addresses) == 0 {
+		return nil
+	}
+	set := make(map[gethcommon.Address]bool, len(addresses))
+	for _, address := range addresses {
+		set[address] = true
+	}
+	return set
+}
@@ -0,0 +1,63 @@
+package distribution_test
+
+import (
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	d := GetTestDistribution()
+
+	view := d.Filter(distribution.Filter{Earners: []common.Address{tests.TestAddresses[0], tests.TestAddresses[2]}})
+	assert.True(t, view.IsReportingView())
+	assert.False(t, d.IsReportingView())
+	assert.Equal(t, 2, view.EarnerCount())
+	assert.Equal(t, d.TokenCount(tests.TestAddresses[2]), view.TokenCount(tests.TestAddresses[2]))
+	assert.Equal(t, 0, view.TokenCount(tests.TestAddresses[1]))
+
+	// the last token is only allocated to the first earner
+	lastToken := tests.TestTokens[len(tests.TestTokens)-1]
+	view = d.Filter(distribution.Filter{Tokens: []common.Address{lastToken}})
+	assert.Equal(t, 1, view.EarnerCount())
+	amount, found := view.Get(tests.TestAddresses[0], lastToken)
+	assert.True(t, found)
+	assert.Equal(t, big.NewInt(int64(len(tests.TestTokens))), amount)
+
+	// an empty filter copies everything
+	view = d.Filter(distribution.Filter{})
+	assert.True(t, distribution.DiffDistributions(d, view).IsEmpty())
+
+	// changes to the view do not affect the original
+	assert.Nil(t, view.Update(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1000)))
+	amount, _ = d.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.Equal(t, big.NewInt(1), amount)
+}
+
+func TestFilterCannotMerklize(t *testing.T) {
+	view := GetTestDistribution().Filter(distribution.Filter{Tokens: tests.TestTokens[:1]})
+
+	_, _, err := view.Merklize()
+	assert.ErrorIs(t, err, distribution.ErrReportingView)
+	_, err = view.MerklizeAccounts()
+	assert.ErrorIs(t, err, distribution.ErrReportingView)
+	_, err = view.Freeze()
+	assert.ErrorIs(t, err, distribution.ErrReportingView)
+	_, err = view.Compact()
+	assert.ErrorIs(t, err, distribution.ErrReportingView)
+}
+
+func TestFilterCannotSnapshot(t *testing.T) {
+	d := GetTestDistribution()
+	accountTree, err := d.MerklizeAccounts()
+	assert.Nil(t, err)
+	view := d.Filter(distribution.Filter{})
+
+	assert.ErrorIs(t, distribution.WriteSnapshot(io.Discard, view, accountTree, time.Now()), distribution.ErrReportingView)
+}
@@ -0,0 +1,85 @@
+package distribution
+
+import (
+	"bytes"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/wealdtech/go-merkletree/v2"
+)
+
+// MerklizedDistribution is a frozen copy of a distribution together with its merkle trees.
+// Freeze is the only step that writes to it, so all of its methods are safe for concurrent use.
+// Changes to the distribution it was frozen from are not reflected; freeze again to pick them up.
+type MerklizedDistribution struct {
+	distribution *Distribution
+	accountTree  *merkletree.MerkleTree
+	tokenTrees   map[gethcommon.Address]*merkletree.MerkleTree
+}
+
+// Freeze copies and merklizes the distribution. The copy is sealed and never exposed,
+// so the indices set by merklizing it cannot go stale.
+func (d *Distribution) Freeze() (*MerklizedDistribution, error) {
+	if d.reportingView {
+		return nil, ErrReportingView
+	}
+	frozen := d.clone()
+	frozen.sealed = true
+
+	accountTree, tokenTrees, err := frozen.Merklize()
+	if err != nil {
+		return nil, err
+	}
+
+	return &MerklizedDistribution{
+		distribution: frozen,
+		accountTree:  accountTree,
+		tokenTrees:   tokenTrees,
+	}, nil
+}
+
+// Root returns the root of the account tree
+func (m *MerklizedDistribution) Root() []byte {
+	return bytes.Clone(m.accountTree.Root())
+}
+
+// AccountTree returns the account tree. It must not be modified.
+func (m *MerklizedDistribution) AccountTree() *merkletree.MerkleTree {
+	return m.accountTree
+}
+
+// GetTokenTree returns the token tree for an earner. It must not be modified.
+func (m *MerklizedDistribution) GetTokenTree(address gethcommon.Address) (*merkletree.MerkleTree, error) {
+	tokenTree, found := m.tokenTrees[address]
+	if !found {
+		return m.distribution.GetTokenTree(address)
+	}
+	return tokenTree, nil
+}
+
+// Get gets a copy of the value for a given address and whether it was in the distribution
+func (m *MerklizedDistribution) Get(address, token gethcommon.Address) (*big.Int, bool) {
+	amount, found := m.distribution.Get(address, token)
+	return copyBigInt(amount), found
+}
+
+// GetAccountIndex gets the index of the account in the account tree
+func (m *MerklizedDistribution) GetAccountIndex(address gethcommon.Address) (uint64, bool) {
+	return m.distribution.GetAccountIndex(address)
+}
+
+// GetTokenIndex gets the index of the token in the account's token tree
+func (m *MerklizedDistribution) GetTokenIndex(address, token gethcommon.Address) (uint64, bool) {
+	return m.distribution.GetTokenIndex(address, token)
+}
+
+// clone returns a deep copy of the distribution's data and settings, without its indices or collisions
+func (d *Distribution) clone() *Distribution {
+	return &Distribution{
+		store:           d.store.clone(),
+		Debug:           d.Debug,
+		DuplicatePolicy: d.DuplicatePolicy,
+		QuoteAmounts:    d.QuoteAmounts,
+		leafScheme:      d.leafScheme,
+	}
+}
@@ -0,0 +1,85 @@
+package distribution_test
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeze(t *testing.T) {
+	d := GetTestDistribution()
+
+	frozen, err := d.Freeze()
+	assert.Nil(t, err)
+
+	accountTree, tokenTrees, err := GetTestDistribution().Merklize()
+	assert.Nil(t, err)
+	assert.Equal(t, accountTree.Root(), frozen.Root())
+
+	// the original distribution is not merklized by freezing it
+	assert.False(t, d.IsMerklized())
+
+	// changes to the original do not affect the frozen copy
+	assert.Nil(t, d.Update(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1000)))
+	amount, found := frozen.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.True(t, found)
+	assert.Equal(t, big.NewInt(1), amount)
+
+	// nor do changes to values it returns
+	amount.SetInt64(2000)
+	amount, _ = frozen.Get(tests.TestAddresses[0], tests.TestTokens[0])
+	assert.Equal(t, big.NewInt(1), amount)
+
+	for i, address := range tests.TestAddresses {
+		index, found := frozen.GetAccountIndex(address)
+		assert.True(t, found)
+		assert.Equal(t, uint64(i), index)
+
+		tokenTree, err := frozen.GetTokenTree(address)
+		assert.Nil(t, err)
+		assert.Equal(t, tokenTrees[address].Root(), tokenTree.Root())
+	}
+}
+
+func TestFreezeConcurrentReads(t *testing.T) {
+	d := GetTestDistribution()
+	frozen, err := d.Freeze()
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				for j, address := range tests.TestAddresses {
+					_, found := frozen.Get(address, tests.TestTokens[0])
+					assert.True(t, found)
+					index, found := frozen.GetTokenIndex(address, tests.TestTokens[0])
+					assert.True(t, found)
+					assert.Equal(t, uint64(0), index)
+					index, _ = frozen.GetAccountIndex(address)
+					assert.Equal(t, uint64(j), index)
+				}
+			}
+		}()
+	}
+
+	// mutating and re-merklizing the original while the frozen copy is read must not race
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, d.Update(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(int64(i))))
+		_, _, err := d.Merklize()
+		assert.Nil(t, err)
+	}
+	wg.Wait()
+}
+
+func TestFreezeInvalidAmount(t *testing.T) {
+	d := distribution.NewDistribution()
+	assert.ErrorIs(t, d.Set(tests.TestAddresses[0], tests.TestTokens[0], nil), distribution.ErrInvalidAmount)
+	assert.Equal(t, 0, d.LeafCount())
+}
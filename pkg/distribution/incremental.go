@@ -0,0 +1,162 @@
+package distribution
+
+import (
+	"errors"
+	"fmt"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/wealdtech/go-merkletree/v2"
+)
+
+var ErrNoPreviousTree = errors.New("previous account tree is required")
+
+// MerklizeIncremental merklizes the distribution reusing the trees from a previous Merklize of it,
+// rebuilding only the token trees of the changed earners. The previous trees are not modified.
+//
+// Every earner whose tokens or amounts changed must be in changed, see Diff.ChangedEarners, and an earner in
+// changed that is neither in the distribution nor in the previous trees is an ErrEarnerNotFound. The trees
+// of the other earners are assumed to be current. When the earners themselves are the same as before,
+// only the account tree paths of the changed earners are rehashed. Otherwise the account tree is rebuilt,
+// still reusing the token roots of the unchanged earners.
+//
+// prevTokenTrees may be nil, as returned by MerklizeAccounts, in which case the returned token trees
+// only include the rebuilt ones.
+func (d *Distribution) MerklizeIncremental(
+	prevAccountTree *merkletree.MerkleTree,
+	prevTokenTrees map[gethcommon.Address]*merkletree.MerkleTree,
+	changed []gethcommon.Address,
+) (*merkletree.MerkleTree, map[gethcommon.Address]*merkletree.MerkleTree, error) {
+	if d.reportingView {
+		return nil, nil, ErrReportingView
+	}
+	if prevAccountTree == nil {
+		return nil, nil, ErrNoPreviousTree
+	}
+	if err := checkLeafCount(d.store.earnerCount()); err != nil {
+		return nil, nil, err
+	}
+
+	// token roots of the unchanged earners, taken from the previous account leafs
+	scheme := d.LeafScheme()
+	prevEarners := make([]gethcommon.Address, 0, len(prevAccountTree.Data))
+	prevTokenRoots := make(map[gethcommon.Address][]byte, len(prevAccountTree.Data))
+	for _, accountLeaf := range prevAccountTree.Data {
+		earner, tokenRoot, err := scheme.DecodeAccountLeaf(accountLeaf)
+		if err != nil {
+			return nil, nil, err
+		}
+		prevEarners = append(prevEarners, earner)
+		prevTokenRoots[earner] = tokenRoot
+	}
+
+	tokenTrees := make(map[gethcommon.Address]*merkletree.MerkleTree, len(prevTokenTrees)+len(changed))
+	for address, tokenTree := range prevTokenTrees {
+		tokenTrees[address] = tokenTree
+	}
+	isChanged := make(map[gethcommon.Address]bool, len(changed))
+	for _, address := range changed {
+		isChanged[address] = true
+		delete(tokenTrees, address)
+	}
+
+	sameEarners := d.store.earnerCount() == len(prevAccountTree.Data)
+	accountIndex := uint64(0)
+	accountLeafs := make([][]byte, 0, d.store.earnerCount())
+	changedIndices := make([]uint64, 0, len(changed))
+	var err error
+	d.store.forEachEarner(func(address gethcommon.Address) bool {
+		tokenRoot, found := prevTokenRoots[address]
+		if !found || isChanged[address] {
+			var tokenTree *merkletree.MerkleTree
+			tokenTree, err = newTokenTree(scheme, d.store, address)
+			if err != nil {
+				return false
+			}
+			tokenTrees[address] = tokenTree
+			tokenRoot = tokenTree.Root()
+			changedIndices = append(changedIndices, accountIndex)
+		}
+		var accountLeaf []byte
+		accountLeaf, err = scheme.EncodeAccountLeaf(address, tokenRoot)
+		if err != nil {
+			return false
+		}
+		if sameEarners && prevEarners[accountIndex] != address {
+			sameEarners = false
+		}
+		accountLeafs = append(accountLeafs, accountLeaf)
+		accountIndex++
+		return true
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	// removed earners are expected in changed, anything else is not an earner
+	for _, address := range changed {
+		_, found := d.store.tokenCount(address)
+		if _, removed := prevTokenRoots[address]; !found && !removed {
+			return nil, nil, fmt.Errorf("%w: %s", ErrEarnerNotFound, address.Hex())
+		}
+	}
+
+	var accountTree *merkletree.MerkleTree
+	if sameEarners {
+		accountTree = rehashPaths(prevAccountTree, accountLeafs, changedIndices)
+	} else if accountTree, err = newKeccakTree(accountLeafs); err != nil {
+		return nil, nil, err
+	}
+	d.setIndices()
+	return accountTree, tokenTrees, nil
+}
+
+// ChangedEarners lists every earner that was added or removed, or whose tokens or amounts changed,
+// for MerklizeIncremental
+func (d *Diff) ChangedEarners() []gethcommon.Address {
+	earners := make([]gethcommon.Address, 0, len(d.Tokens)+len(d.AmountChanges))
+	seen := make(map[gethcommon.Address]bool, cap(earners))
+	for _, changes := range d.Tokens {
+		if !seen[changes.Earner] {
+			seen[changes.Earner] = true
+			earners = append(earners, changes.Earner)
+		}
+	}
+	for _, change := range d.AmountChanges {
+		if !seen[change.Earner] {
+			seen[change.Earner] = true
+			earners = append(earners, change.Earner)
+		}
+	}
+	return earners
+}
+
+// rehashPaths copies a keccak tree with the leafs at the given indices replaced, rehashing only their paths to the root
+func rehashPaths(tree *merkletree.MerkleTree, leafs [][]byte, indices []uint64) *merkletree.MerkleTree {
+	hash := keccakHash
+	nodes := make([][]byte, len(tree.Nodes))
+	copy(nodes, tree.Nodes)
+
+	branchesLen := uint64(len(nodes) / 2)
+	for _, index := range indices {
+		nodes[branchesLen+index] = hash.Hash(leafs[index])
+	}
+	// rehash each level once, parents of the same changed branches are shared
+	dirty := indices
+	for offset := branchesLen; offset > 1; offset /= 2 {
+		parents := make([]uint64, 0, len(dirty))
+		for _, index := range dirty {
+			parent := (offset + index) / 2
+			if len(parents) > 0 && parents[len(parents)-1] == parent-offset/2 {
+				continue
+			}
+			nodes[parent] = hash.Hash(nodes[parent*2], nodes[parent*2+1])
+			parents = append(parents, parent-offset/2)
+		}
+		dirty = parents
+	}
+
+	return &merkletree.MerkleTree{
+		Hash:  hash,
+		Data:  leafs,
+		Nodes: nodes,
+	}
+}
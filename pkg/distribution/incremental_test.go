@@ -0,0 +1,128 @@
+package distribution_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-merkletree/v2"
+)
+
+func assertSameTrees(
+	t *testing.T,
+	d *distribution.Distribution,
+	accountTree *merkletree.MerkleTree,
+	tokenTrees map[common.Address]*merkletree.MerkleTree,
+) {
+	expected, err := d.Freeze()
+	assert.Nil(t, err)
+	assert.Equal(t, expected.AccountTree().Nodes, accountTree.Nodes)
+	assert.Equal(t, expected.AccountTree().Data, accountTree.Data)
+	d.ForEachEarner(func(earner common.Address) bool {
+		expectedTokenTree, err := expected.GetTokenTree(earner)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedTokenTree.Nodes, tokenTrees[earner].Nodes)
+
+		index, found := d.GetAccountIndex(earner)
+		assert.True(t, found)
+		expectedIndex, _ := expected.GetAccountIndex(earner)
+		assert.Equal(t, expectedIndex, index)
+		return true
+	})
+}
+
+func TestMerklizeIncrementalAmountChanges(t *testing.T) {
+	d, err := distribution.NewDistributionFromReader(strings.NewReader(getFullTestEarnerLines()))
+	assert.Nil(t, err)
+	prev, err := d.Freeze()
+	assert.Nil(t, err)
+	accountTree, tokenTrees, err := d.Merklize()
+	assert.Nil(t, err)
+	prevNodes := append([][]byte{}, accountTree.Nodes...)
+
+	// change a few earners spread over the tree, including neighbours sharing a parent
+	earners := make([]common.Address, 0, d.EarnerCount())
+	d.ForEachEarner(func(earner common.Address) bool {
+		earners = append(earners, earner)
+		return true
+	})
+	changed := []common.Address{earners[0], earners[1], earners[7], earners[len(earners)-1]}
+	for _, earner := range changed {
+		d.ForEachToken(earner, func(token common.Address, amount *big.Int) bool {
+			assert.Nil(t, d.Update(earner, token, new(big.Int).Add(amount, big.NewInt(1))))
+			return false
+		})
+	}
+
+	next, err := d.Freeze()
+	assert.Nil(t, err)
+	diff := distribution.DiffDistributions(cloneDistribution(t, prev), cloneDistribution(t, next))
+	assert.ElementsMatch(t, changed, diff.ChangedEarners())
+
+	incrementalAccountTree, incrementalTokenTrees, err := d.MerklizeIncremental(accountTree, tokenTrees, diff.ChangedEarners())
+	assert.Nil(t, err)
+	assertSameTrees(t, d, incrementalAccountTree, incrementalTokenTrees)
+	assert.NotEqual(t, prev.Root(), incrementalAccountTree.Root())
+
+	// the previous trees are untouched
+	assert.Equal(t, prevNodes, accountTree.Nodes)
+	assert.Equal(t, prev.Root(), accountTree.Root())
+}
+
+func TestMerklizeIncrementalEarnerChanges(t *testing.T) {
+	d := GetTestDistribution()
+	accountTree, tokenTrees, err := d.Merklize()
+	assert.Nil(t, err)
+
+	// remove an earner and add a token to another
+	assert.Nil(t, d.DeleteEarner(tests.TestAddresses[2]))
+	assert.Nil(t, d.Set(tests.TestAddresses[1], tests.TestTokens[len(tests.TestTokens)-1], big.NewInt(100)))
+	changed := []common.Address{tests.TestAddresses[1], tests.TestAddresses[2]}
+
+	incrementalAccountTree, incrementalTokenTrees, err := d.MerklizeIncremental(accountTree, tokenTrees, changed)
+	assert.Nil(t, err)
+	assertSameTrees(t, d, incrementalAccountTree, incrementalTokenTrees)
+	assert.NotContains(t, incrementalTokenTrees, tests.TestAddresses[2])
+
+	// without the previous token trees only the rebuilt ones are returned
+	incrementalAccountTree, incrementalTokenTrees, err = d.MerklizeIncremental(accountTree, nil, changed)
+	assert.Nil(t, err)
+	assert.Len(t, incrementalTokenTrees, 1)
+	expected, err := d.Freeze()
+	assert.Nil(t, err)
+	assert.Equal(t, expected.AccountTree().Nodes, incrementalAccountTree.Nodes)
+
+	// an unknown earner is rejected even when the earners changed
+	_, _, err = d.MerklizeIncremental(accountTree, tokenTrees, append(changed, tests.TestTokens[0]))
+	assert.ErrorIs(t, err, distribution.ErrEarnerNotFound)
+}
+
+func TestMerklizeIncrementalNoChanges(t *testing.T) {
+	d := GetTestDistribution()
+	accountTree, tokenTrees, err := d.Merklize()
+	assert.Nil(t, err)
+
+	incrementalAccountTree, incrementalTokenTrees, err := d.MerklizeIncremental(accountTree, tokenTrees, nil)
+	assert.Nil(t, err)
+	assertSameTrees(t, d, incrementalAccountTree, incrementalTokenTrees)
+
+	_, _, err = d.MerklizeIncremental(accountTree, tokenTrees, []common.Address{tests.TestTokens[0]})
+	assert.ErrorIs(t, err, distribution.ErrEarnerNotFound)
+
+	_, _, err = d.MerklizeIncremental(nil, tokenTrees, nil)
+	assert.ErrorIs(t, err, distribution.ErrNoPreviousTree)
+}
+
+// cloneDistribution copies a frozen distribution into a plain one for diffing
+func cloneDistribution(t *testing.T, m *distribution.MerklizedDistribution) *distribution.Distribution {
+	d := distribution.NewDistribution()
+	m.ForEachLeaf(func(earner, token common.Address, amount *big.Int) bool {
+		assert.Nil(t, d.Set(earner, token, amount))
+		return true
+	})
+	return d
+}
@@ -0,0 +1,84 @@
+package distribution
+
+import (
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// EarnerCount returns the number of earners in the distribution
+func (d *Distribution) EarnerCount() int {
+	return d.store.earnerCount()
+}
+
+// LeafCount returns the number of earner and token pairs in the distribution
+func (d *Distribution) LeafCount() int {
+	return d.store.leafCount()
+}
+
+// TokenCount returns the number of tokens an earner has, or 0 if the earner is not in the distribution
+func (d *Distribution) TokenCount(earner gethcommon.Address) int {
+	tokenCount, _ := d.store.tokenCount(earner)
+	return tokenCount
+}
+
+// ForEachEarner calls fn for each earner in tree order, stopping early if fn returns false.
+// The distribution must not be changed from fn.
+func (d *Distribution) ForEachEarner(fn func(earner gethcommon.Address) bool) {
+	d.store.forEachEarner(fn)
+}
+
+// ForEachToken calls fn for each token of an earner in tree order with a copy of its amount,
+// stopping early if fn returns false. It returns false if the earner is not in the distribution.
+// The distribution must not be changed from fn.
+func (d *Distribution) ForEachToken(earner gethcommon.Address, fn func(token gethcommon.Address, amount *big.Int) bool) bool {
+	return d.store.forEachToken(earner, func(token gethcommon.Address, amount *big.Int) bool {
+		return fn(token, copyBigInt(amount))
+	})
+}
+
+// ForEachLeaf calls fn for each earner and token in tree order with a copy of its amount,
+// stopping early if fn returns false. The distribution must not be changed from fn.
+func (d *Distribution) ForEachLeaf(fn func(earner, token gethcommon.Address, amount *big.Int) bool) {
+	d.store.forEachEarner(func(earner gethcommon.Address) bool {
+		more := true
+		d.store.forEachToken(earner, func(token gethcommon.Address, amount *big.Int) bool {
+			more = fn(earner, token, copyBigInt(amount))
+			return more
+		})
+		return more
+	})
+}
+
+// EarnerCount returns the number of earners in the distribution
+func (m *MerklizedDistribution) EarnerCount() int {
+	return m.distribution.EarnerCount()
+}
+
+// TokenCount returns the number of tokens an earner has, or 0 if the earner is not in the distribution
+func (m *MerklizedDistribution) TokenCount(earner gethcommon.Address) int {
+	return m.distribution.TokenCount(earner)
+}
+
+// ForEachEarner is Distribution.ForEachEarner, and is safe for concurrent use
+func (m *MerklizedDistribution) ForEachEarner(fn func(earner gethcommon.Address) bool) {
+	m.distribution.ForEachEarner(fn)
+}
+
+// ForEachToken is Distribution.ForEachToken, and is safe for concurrent use
+func (m *MerklizedDistribution) ForEachToken(earner gethcommon.Address, fn func(token gethcommon.Address, amount *big.Int) bool) bool {
+	return m.distribution.ForEachToken(earner, fn)
+}
+
+// ForEachLeaf is Distribution.ForEachLeaf, and is safe for concurrent use
+func (m *MerklizedDistribution) ForEachLeaf(fn func(earner, token gethcommon.Address, amount *big.Int) bool) {
+	m.distribution.ForEachLeaf(fn)
+}
+
+// copyBigInt copies an amount so callers cannot change the distribution through it
+func copyBigInt(amount *big.Int) *big.Int {
+	if amount == nil {
+		return nil
+	}
+	return new(big.Int).Set(amount)
+}
@@ -0,0 +1,89 @@
+package distribution_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachEarner(t *testing.T) {
+	d := GetTestDistribution()
+	assert.Equal(t, len(tests.TestAddresses), d.EarnerCount())
+
+	earners := make([]common.Address, 0)
+	d.ForEachEarner(func(earner common.Address) bool {
+		earners = append(earners, earner)
+		return true
+	})
+	assert.Equal(t, tests.TestAddresses, earners)
+
+	// stop early
+	earners = earners[:0]
+	d.ForEachEarner(func(earner common.Address) bool {
+		earners = append(earners, earner)
+		return len(earners) < 2
+	})
+	assert.Equal(t, tests.TestAddresses[:2], earners)
+}
+
+func TestForEachToken(t *testing.T) {
+	d := GetTestDistribution()
+	assert.Equal(t, len(tests.TestTokens)-1, d.TokenCount(tests.TestAddresses[1]))
+	assert.Equal(t, 0, d.TokenCount(tests.TestTokens[0]))
+
+	tokens := make([]common.Address, 0)
+	found := d.ForEachToken(tests.TestAddresses[1], func(token common.Address, amount *big.Int) bool {
+		assert.Equal(t, big.NewInt(int64(len(tokens)+2)), amount)
+		tokens = append(tokens, token)
+
+		// changing the copy must not change the distribution
+		amount.SetInt64(1000)
+		return true
+	})
+	assert.True(t, found)
+	assert.Equal(t, tests.TestTokens[:len(tests.TestTokens)-1], tokens)
+
+	amount, _ := d.Get(tests.TestAddresses[1], tests.TestTokens[0])
+	assert.Equal(t, big.NewInt(2), amount)
+
+	calls := 0
+	found = d.ForEachToken(tests.TestAddresses[1], func(token common.Address, amount *big.Int) bool {
+		calls++
+		return false
+	})
+	assert.True(t, found)
+	assert.Equal(t, 1, calls)
+
+	found = d.ForEachToken(tests.TestTokens[0], func(token common.Address, amount *big.Int) bool {
+		t.Fail()
+		return true
+	})
+	assert.False(t, found)
+}
+
+func TestForEachLeaf(t *testing.T) {
+	d := GetTestDistribution()
+
+	leaves := 0
+	total := new(big.Int)
+	d.ForEachLeaf(func(earner, token common.Address, amount *big.Int) bool {
+		leaves++
+		total.Add(total, amount)
+		return true
+	})
+	assert.Equal(t, 15, leaves)
+	assert.Equal(t, big.NewInt(55), total)
+
+	frozen, err := d.Freeze()
+	assert.Nil(t, err)
+	leaves = 0
+	frozen.ForEachLeaf(func(earner, token common.Address, amount *big.Int) bool {
+		leaves++
+		return leaves < 3
+	})
+	assert.Equal(t, 3, leaves)
+	assert.Equal(t, d.EarnerCount(), frozen.EarnerCount())
+}
@@ -0,0 +1,70 @@
+package distribution
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wealdtech/go-merkletree/v2"
+	"github.com/wealdtech/go-merkletree/v2/keccak256"
+)
+
+var ErrEmptyTree = errors.New("tree must have at least one leaf")
+
+// keccakHash is the hash type of every tree, it is stateless so it is shared
+var keccakHash = keccak256.New()
+
+// keccakStates are reused across trees, building thousands of small token trees would otherwise
+// allocate a hasher per node
+var keccakStates = sync.Pool{
+	New: func() any {
+		return crypto.NewKeccakState()
+	},
+}
+
+// newKeccakTree creates a keccak256 merkle tree over the leafs, the same as the RewardsCoordinator.
+//
+// The tree is byte for byte the one merkletree.NewTree builds with keccak256: leafs are hashed and padded
+// with zero hashes up to a power of two, Nodes[1] is the root and Nodes[0] is unused. All nodes are stored
+// in a single buffer.
+func newKeccakTree(leafs [][]byte) (*merkletree.MerkleTree, error) {
+	if len(leafs) == 0 {
+		return nil, ErrEmptyTree
+	}
+
+	branchesLen := nextPowerOfTwo(uint64(len(leafs)))
+	buf := make([]byte, 2*branchesLen*32)
+	nodes := make([][]byte, 2*branchesLen)
+	for i := uint64(1); i < uint64(len(nodes)); i++ {
+		nodes[i] = buf[i*32 : (i+1)*32 : (i+1)*32]
+	}
+
+	state := keccakStates.Get().(crypto.KeccakState)
+	defer keccakStates.Put(state)
+	for i, leaf := range leafs {
+		state.Reset()
+		state.Write(leaf)
+		state.Read(nodes[branchesLen+uint64(i)])
+	}
+	// the padding is already zero
+	for i := branchesLen - 1; i > 0; i-- {
+		state.Reset()
+		state.Write(buf[i*2*32 : (i*2+2)*32])
+		state.Read(nodes[i])
+	}
+
+	return &merkletree.MerkleTree{
+		Hash:  keccakHash,
+		Data:  leafs,
+		Nodes: nodes,
+	}, nil
+}
+
+// nextPowerOfTwo returns the smallest power of two that is at least n
+func nextPowerOfTwo(n uint64) uint64 {
+	p := uint64(1)
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
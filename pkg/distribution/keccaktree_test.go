@@ -0,0 +1,100 @@
+package distribution_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-merkletree/v2"
+	"github.com/wealdtech/go-merkletree/v2/keccak256"
+)
+
+// getSizedDistribution creates a distribution of n earners with between 1 and 7 tokens each
+func getSizedDistribution(t testing.TB, n int) *distribution.Distribution {
+	d := distribution.NewDistribution()
+	for i := 0; i < n; i++ {
+		earner := common.BigToAddress(big.NewInt(int64(1_000_000 + i)))
+		for j := 0; j <= i%7; j++ {
+			token := common.BigToAddress(big.NewInt(int64(j + 1)))
+			assert.Nil(t, d.Set(earner, token, big.NewInt(int64(i*31+j))))
+		}
+	}
+	return d
+}
+
+// merklizeWithMerkletree builds the trees of a distribution with go-merkletree, as Merklize used to
+func merklizeWithMerkletree(t testing.TB, d *distribution.Distribution) (*merkletree.MerkleTree, map[common.Address]*merkletree.MerkleTree) {
+	tokenTrees := make(map[common.Address]*merkletree.MerkleTree)
+	accountLeafs := make([][]byte, 0)
+	d.ForEachEarner(func(earner common.Address) bool {
+		tokenLeafs := make([][]byte, 0)
+		d.ForEachToken(earner, func(token common.Address, amount *big.Int) bool {
+			tokenLeafs = append(tokenLeafs, distribution.EncodeTokenLeaf(token, amount))
+			return true
+		})
+		tokenTree, err := merkletree.NewTree(merkletree.WithData(tokenLeafs), merkletree.WithHashType(keccak256.New()))
+		assert.Nil(t, err)
+		tokenTrees[earner] = tokenTree
+		accountLeafs = append(accountLeafs, distribution.EncodeAccountLeaf(earner, tokenTree.Root()))
+		return true
+	})
+	accountTree, err := merkletree.NewTree(merkletree.WithData(accountLeafs), merkletree.WithHashType(keccak256.New()))
+	assert.Nil(t, err)
+	return accountTree, tokenTrees
+}
+
+func TestMerklizeMatchesMerkletree(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 5, 8, 17, 100} {
+		d := getSizedDistribution(t, n)
+		accountTree, tokenTrees, err := d.Merklize()
+		assert.Nil(t, err)
+
+		expectedAccountTree, expectedTokenTrees := merklizeWithMerkletree(t, d)
+		assert.Equal(t, expectedAccountTree.Nodes, accountTree.Nodes, n)
+		assert.Equal(t, expectedAccountTree.Data, accountTree.Data, n)
+		assert.Equal(t, len(expectedTokenTrees), len(tokenTrees), n)
+		for earner, expectedTokenTree := range expectedTokenTrees {
+			assert.Equal(t, expectedTokenTree.Nodes, tokenTrees[earner].Nodes, n)
+		}
+
+		for i := range accountTree.Data {
+			expectedProof, err := expectedAccountTree.GenerateProofWithIndex(uint64(i), 0)
+			assert.Nil(t, err)
+			proof, err := accountTree.GenerateProofWithIndex(uint64(i), 0)
+			assert.Nil(t, err)
+			assert.Equal(t, expectedProof, proof, n)
+
+			verified, err := merkletree.VerifyProofUsing(accountTree.Data[i], false, proof, [][]byte{accountTree.Root()}, keccak256.New())
+			assert.Nil(t, err)
+			assert.True(t, verified, n)
+		}
+	}
+}
+
+func TestMerklizeEmptyDistribution(t *testing.T) {
+	_, _, err := distribution.NewDistribution().Merklize()
+	assert.ErrorIs(t, err, distribution.ErrEmptyTree)
+}
+
+func BenchmarkMerklize(b *testing.B) {
+	for _, n := range []int{10, 1000, 10000} {
+		d := getSizedDistribution(b, n)
+		b.Run(fmt.Sprintf("native/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := d.Merklize(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("merkletree/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				merklizeWithMerkletree(b, d)
+			}
+		})
+	}
+}
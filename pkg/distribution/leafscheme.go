@@ -0,0 +1,106 @@
+package distribution
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+var ErrUnsupportedLeafScheme = errors.New("unsupported leaf scheme")
+var ErrLeafSchemeRegistered = errors.New("leaf scheme version already registered")
+var ErrInvalidAccountLeaf = errors.New("invalid account leaf")
+
+// LeafScheme encodes the leafs of the account and token trees the way a version of the RewardsCoordinator
+// expects them. The trees themselves are always keccak256 merkle trees padded to a power of two.
+type LeafScheme interface {
+	// Version identifies the scheme, see GetLeafScheme
+	Version() uint16
+	// EncodeAccountLeaf encodes the leaf of an earner with the root of its token tree
+	EncodeAccountLeaf(earner gethcommon.Address, tokenRoot []byte) ([]byte, error)
+	// DecodeAccountLeaf returns the earner and token root of an account leaf
+	DecodeAccountLeaf(leaf []byte) (gethcommon.Address, []byte, error)
+	// EncodeTokenLeaf encodes the leaf of a token and the cumulative amount earned
+	EncodeTokenLeaf(token gethcommon.Address, amount *big.Int) ([]byte, error)
+}
+
+// LeafSchemeV1 is the leaf layout of the current RewardsCoordinator, and the default for every distribution:
+//
+//	account leaf: EARNER_LEAF_SALT || earner || token root
+//	token leaf:   TOKEN_LEAF_SALT || token || uint256 amount
+var LeafSchemeV1 LeafScheme = leafSchemeV1{}
+
+type leafSchemeV1 struct{}
+
+func (leafSchemeV1) Version() uint16 {
+	return 1
+}
+
+func (leafSchemeV1) EncodeAccountLeaf(earner gethcommon.Address, tokenRoot []byte) ([]byte, error) {
+	return EncodeAccountLeafChecked(earner, tokenRoot)
+}
+
+func (leafSchemeV1) DecodeAccountLeaf(leaf []byte) (gethcommon.Address, []byte, error) {
+	if len(leaf) != 1+20+32 || leaf[0] != EARNER_LEAF_SALT[0] {
+		return gethcommon.Address{}, nil, fmt.Errorf("%w: %x", ErrInvalidAccountLeaf, leaf)
+	}
+	return gethcommon.BytesToAddress(leaf[1:21]), leaf[21:], nil
+}
+
+func (leafSchemeV1) EncodeTokenLeaf(token gethcommon.Address, amount *big.Int) ([]byte, error) {
+	return EncodeTokenLeafChecked(token, amount)
+}
+
+var leafSchemesMu sync.RWMutex
+var leafSchemes = map[uint16]LeafScheme{1: LeafSchemeV1}
+
+// RegisterLeafScheme makes a scheme available to GetLeafScheme, for reading snapshots and dumps that use it
+func RegisterLeafScheme(scheme LeafScheme) error {
+	leafSchemesMu.Lock()
+	defer leafSchemesMu.Unlock()
+	if _, found := leafSchemes[scheme.Version()]; found {
+		return fmt.Errorf("%w: %d", ErrLeafSchemeRegistered, scheme.Version())
+	}
+	leafSchemes[scheme.Version()] = scheme
+	return nil
+}
+
+// GetLeafScheme returns the registered scheme with the given version
+func GetLeafScheme(version uint16) (LeafScheme, error) {
+	leafSchemesMu.RLock()
+	defer leafSchemesMu.RUnlock()
+	scheme, found := leafSchemes[version]
+	if !found {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedLeafScheme, version)
+	}
+	return scheme, nil
+}
+
+// NewDistributionWithLeafScheme creates an empty distribution whose trees are built with the given leaf scheme
+func NewDistributionWithLeafScheme(scheme LeafScheme) *Distribution {
+	distro := NewDistribution()
+	distro.leafScheme = scheme
+	return distro
+}
+
+// LeafScheme returns the leaf scheme the distribution is merklized with
+func (d *Distribution) LeafScheme() LeafScheme {
+	if d.leafScheme == nil {
+		return LeafSchemeV1
+	}
+	return d.leafScheme
+}
+
+// NewCompactDistributionWithLeafScheme creates an empty compact distribution whose trees are built with the given leaf scheme
+func NewCompactDistributionWithLeafScheme(scheme LeafScheme) *Distribution {
+	distro := NewCompactDistribution()
+	distro.leafScheme = scheme
+	return distro
+}
+
+// LeafScheme returns the leaf scheme the distribution was merklized with
+func (m *MerklizedDistribution) LeafScheme() LeafScheme {
+	return m.distribution.LeafScheme()
+}
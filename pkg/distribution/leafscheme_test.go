@@ -0,0 +1,136 @@
+package distribution_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// testLeafScheme swaps the order of the fields in each leaf
+type testLeafScheme struct{}
+
+func (testLeafScheme) Version() uint16 {
+	return 99
+}
+
+func (testLeafScheme) EncodeAccountLeaf(earner common.Address, tokenRoot []byte) ([]byte, error) {
+	return append(append([]byte{0xe0}, tokenRoot...), earner.Bytes()...), nil
+}
+
+func (testLeafScheme) DecodeAccountLeaf(leaf []byte) (common.Address, []byte, error) {
+	return common.BytesToAddress(leaf[33:]), leaf[1:33], nil
+}
+
+func (testLeafScheme) EncodeTokenLeaf(token common.Address, amount *big.Int) ([]byte, error) {
+	return append(append([]byte{0xe1}, common.LeftPadBytes(amount.Bytes(), 32)...), token.Bytes()...), nil
+}
+
+var registerTestLeafScheme sync.Once
+
+func getTestLeafSchemeDistribution(t *testing.T) *distribution.Distribution {
+	registerTestLeafScheme.Do(func() {
+		assert.Nil(t, distribution.RegisterLeafScheme(testLeafScheme{}))
+	})
+	d := distribution.NewDistributionWithLeafScheme(testLeafScheme{})
+	GetTestDistribution().ForEachLeaf(func(earner, token common.Address, amount *big.Int) bool {
+		assert.Nil(t, d.Set(earner, token, amount))
+		return true
+	})
+	return d
+}
+
+func TestLeafSchemeDefault(t *testing.T) {
+	assert.Equal(t, distribution.LeafSchemeV1, distribution.NewDistribution().LeafScheme())
+
+	scheme, err := distribution.GetLeafScheme(1)
+	assert.Nil(t, err)
+	assert.Equal(t, distribution.LeafSchemeV1, scheme)
+
+	_, err = distribution.GetLeafScheme(1000)
+	assert.ErrorIs(t, err, distribution.ErrUnsupportedLeafScheme)
+	assert.ErrorIs(t, distribution.RegisterLeafScheme(distribution.LeafSchemeV1), distribution.ErrLeafSchemeRegistered)
+
+	leaf, err := distribution.LeafSchemeV1.EncodeAccountLeaf(tests.TestAddresses[0], make([]byte, 32))
+	assert.Nil(t, err)
+	assert.Equal(t, distribution.EncodeAccountLeaf(tests.TestAddresses[0], make([]byte, 32)), leaf)
+	earner, tokenRoot, err := distribution.LeafSchemeV1.DecodeAccountLeaf(leaf)
+	assert.Nil(t, err)
+	assert.Equal(t, tests.TestAddresses[0], earner)
+	assert.Equal(t, make([]byte, 32), tokenRoot)
+
+	_, _, err = distribution.LeafSchemeV1.DecodeAccountLeaf(leaf[1:])
+	assert.ErrorIs(t, err, distribution.ErrInvalidAccountLeaf)
+}
+
+func TestMerklizeWithLeafScheme(t *testing.T) {
+	d := getTestLeafSchemeDistribution(t)
+	assert.Equal(t, uint16(99), d.LeafScheme().Version())
+
+	accountTree, tokenTrees, err := d.Merklize()
+	assert.Nil(t, err)
+	v1AccountTree, _, err := GetTestDistribution().Merklize()
+	assert.Nil(t, err)
+	assert.NotEqual(t, v1AccountTree.Root(), accountTree.Root())
+	assert.Equal(t, byte(0xe0), accountTree.Data[0][0])
+
+	// every way of merklizing uses the distribution's scheme
+	parallelAccountTree, _, err := d.MerklizeParallel(context.Background(), 2, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, accountTree.Root(), parallelAccountTree.Root())
+
+	lightAccountTree, err := d.MerklizeAccounts()
+	assert.Nil(t, err)
+	assert.Equal(t, accountTree.Root(), lightAccountTree.Root())
+
+	tokenTree, err := d.GetTokenTree(tests.TestAddresses[0])
+	assert.Nil(t, err)
+	assert.Equal(t, tokenTrees[tests.TestAddresses[0]].Root(), tokenTree.Root())
+
+	compact, err := d.Compact()
+	assert.Nil(t, err)
+	compactAccountTree, err := compact.MerklizeAccounts()
+	assert.Nil(t, err)
+	assert.Equal(t, accountTree.Root(), compactAccountTree.Root())
+
+	frozen, err := d.Freeze()
+	assert.Nil(t, err)
+	assert.Equal(t, accountTree.Root(), frozen.Root())
+
+	assert.Nil(t, d.Update(tests.TestAddresses[1], tests.TestTokens[0], big.NewInt(1000)))
+	incrementalAccountTree, _, err := d.MerklizeIncremental(accountTree, tokenTrees, []common.Address{tests.TestAddresses[1]})
+	assert.Nil(t, err)
+	expectedAccountTree, _, err := d.Merklize()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedAccountTree.Nodes, incrementalAccountTree.Nodes)
+}
+
+func TestSerializeWithLeafScheme(t *testing.T) {
+	d := getTestLeafSchemeDistribution(t)
+	frozen, err := d.Freeze()
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, distribution.WriteTreeDump(&buf, frozen))
+	dump, rebuilt, err := distribution.ReadTreeDump(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(99), dump.LeafScheme)
+	assert.Equal(t, frozen.Root(), rebuilt.Root())
+
+	// snapshots are written from a merklized distribution, freezing only merklizes the frozen copy
+	buf.Reset()
+	accountTree, err := d.MerklizeAccounts()
+	assert.Nil(t, err)
+	assert.Nil(t, distribution.WriteSnapshot(&buf, d, accountTree, time.Unix(1714780800, 0)))
+	snapshot, err := distribution.ReadSnapshot(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, frozen.AccountTree().Data, snapshot.AccountTree.Data)
+	assert.Equal(t, uint16(99), snapshot.Distribution.LeafScheme().Version())
+}
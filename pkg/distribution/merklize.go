@@ -0,0 +1,121 @@
+package distribution
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/wealdtech/go-merkletree/v2"
+)
+
+// MerklizeProgressFunc is called after each token tree is built with the number of trees built so far and the total.
+// It is always called from the goroutine that called MerklizeParallel.
+type MerklizeProgressFunc func(done, total int)
+
+type tokenTreeResult struct {
+	index int
+	tree  *merkletree.MerkleTree
+	err   error
+}
+
+// MerklizeParallel is the same as Merklize but builds the token trees with a pool of workers.
+// If workers is less than 1 it defaults to the number of CPUs. progress may be nil.
+// The account tree is identical to the one built by Merklize.
+func (d *Distribution) MerklizeParallel(
+	ctx context.Context,
+	workers int,
+	progress MerklizeProgressFunc,
+) (*merkletree.MerkleTree, map[gethcommon.Address]*merkletree.MerkleTree, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if d.reportingView {
+		return nil, nil, ErrReportingView
+	}
+	scheme := d.LeafScheme()
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	if err := checkLeafCount(d.store.earnerCount()); err != nil {
+		return nil, nil, err
+	}
+
+	// collect the earners up front so the workers only read the distribution
+	jobs := make([]gethcommon.Address, 0, d.store.earnerCount())
+	d.store.forEachEarner(func(earner gethcommon.Address) bool {
+		jobs = append(jobs, earner)
+		return true
+	})
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	queue := make(chan int)
+	results := make(chan tokenTreeResult, len(jobs))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				// drain the queue without building anything once cancelled
+				if ctx.Err() != nil {
+					continue
+				}
+				tree, err := newTokenTree(scheme, d.store, jobs[i])
+				results <- tokenTreeResult{index: i, tree: tree, err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(queue)
+		for i := range jobs {
+			select {
+			case queue <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	// stop queueing and wait for the workers on every return path so none are left running
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	trees := make([]*merkletree.MerkleTree, len(jobs))
+	for done := 0; done < len(jobs); done++ {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case result := <-results:
+			if result.err != nil {
+				return nil, nil, result.err
+			}
+			trees[result.index] = result.tree
+		}
+		if progress != nil {
+			progress(done+1, len(jobs))
+		}
+	}
+
+	tokenTrees := make(map[gethcommon.Address]*merkletree.MerkleTree, len(jobs))
+	accountLeafs := make([][]byte, 0, len(jobs))
+	for i, address := range jobs {
+		tokenTrees[address] = trees[i]
+		accountLeaf, err := scheme.EncodeAccountLeaf(address, trees[i].Root())
+		if err != nil {
+			return nil, nil, err
+		}
+		accountLeafs = append(accountLeafs, accountLeaf)
+	}
+
+	accountTree, err := newKeccakTree(accountLeafs)
+	if err != nil {
+		return nil, nil, err
+	}
+	d.setIndices()
+
+	return accountTree, tokenTrees, nil
+}
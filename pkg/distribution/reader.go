@@ -0,0 +1,115 @@
+package distribution
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// MaxLineSize is the longest NDJSON line accepted by LoadFromReader
+const MaxLineSize = 1024 * 1024
+
+// LineError reports a failure to parse a single line of NDJSON input
+type LineError struct {
+	Line int // 1-based
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidLinesError reports every line that failed validation while loading a distribution
+type InvalidLinesError struct {
+	Lines []*LineError
+}
+
+func (e *InvalidLinesError) Error() string {
+	msgs := make([]string, 0, len(e.Lines))
+	for _, l := range e.Lines {
+		msgs = append(msgs, l.Error())
+	}
+	return fmt.Sprintf("%d invalid lines: %s", len(e.Lines), strings.Join(msgs, "; "))
+}
+
+func (e *InvalidLinesError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Lines))
+	for _, l := range e.Lines {
+		errs = append(errs, l)
+	}
+	return errs
+}
+
+// leaf is the parsed form of an EarnerLine, which is much smaller than holding on to the raw line
+type leaf struct {
+	earner gethcommon.Address
+	token  gethcommon.Address
+	amount *big.Int
+}
+
+// NewDistributionFromReader creates a distribution from newline delimited EarnerLine JSON
+func NewDistributionFromReader(r io.Reader) (*Distribution, error) {
+	distro := NewDistribution()
+	if err := distro.LoadFromReader(r); err != nil {
+		return nil, err
+	}
+	return distro, nil
+}
+
+// LoadFromReader loads newline delimited EarnerLine JSON into the distribution.
+// The input is consumed line by line, so only the parsed leaves are held in memory, not the raw input.
+// Lines may appear in any order; they are sorted before being set.
+// If any line is invalid nothing is set and an *InvalidLinesError listing every invalid line is returned.
+func (d *Distribution) LoadFromReader(r io.Reader) error {
+	leaves, err := readLeaves(r)
+	if err != nil {
+		return err
+	}
+	return d.setLeaves(leaves)
+}
+
+// readLeaves parses newline delimited EarnerLine JSON, reporting every invalid line
+func readLeaves(r io.Reader) ([]leaf, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineSize)
+
+	leaves := make([]leaf, 0)
+	invalid := make([]*LineError, 0)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		line := EarnerLine{}
+		if err := json.Unmarshal(raw, &line); err != nil {
+			invalid = append(invalid, &LineError{Line: lineNumber, Err: fmt.Errorf("failed to unmarshal line: %w", err)})
+			continue
+		}
+		l, err := line.parse()
+		if err != nil {
+			invalid = append(invalid, &LineError{Line: lineNumber, Err: err})
+			continue
+		}
+		leaves = append(leaves, l)
+	}
+	if err := scanner.Err(); err != nil {
+		invalid = append(invalid, &LineError{Line: lineNumber + 1, Err: err})
+	}
+	if len(invalid) > 0 {
+		return nil, &InvalidLinesError{Lines: invalid}
+	}
+	return leaves, nil
+}
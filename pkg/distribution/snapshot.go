@@ -0,0 +1,331 @@
+package distribution
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math/big"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/wealdtech/go-merkletree/v2"
+	"github.com/wealdtech/go-merkletree/v2/keccak256"
+)
+
+// SnapshotVersion is the version of the snapshot file format written by WriteSnapshot
+const SnapshotVersion = 1
+
+var snapshotMagic = [4]byte{'E', 'L', 'R', 'S'}
+
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+var ErrUnsupportedSnapshotVersion = errors.New("unsupported snapshot version")
+var ErrSnapshotChecksumMismatch = errors.New("snapshot checksum mismatch")
+var ErrNotMerklized = errors.New("distribution is not merklized or changed since")
+
+// Snapshot is a merklized distribution loaded by ReadSnapshot.
+// The distribution's account and token indices are set, so it is ready for claimgen.GetProofForEarner
+// with a nil map of token trees.
+type Snapshot struct {
+	SnapshotDate time.Time
+	Root         []byte
+	Distribution *Distribution
+	AccountTree  *merkletree.MerkleTree
+}
+
+// WriteSnapshot writes a merklized distribution to w.
+// accountTree must be the account tree returned by the last merklization of d, and reporting views are rejected.
+// Each token root in accountTree is checked against the token tree of the earner's amounts.
+//
+// Format, integers are big-endian and counts are uvarints:
+//
+//	magic "ELRS" | version uint16 | leaf scheme version uint16 | snapshot date unix seconds int64 | root [32]byte
+//	earner count | per earner: earner [20]byte | token root [32]byte | token count |
+//	    per token: token [20]byte | amount length uint8 | amount bytes
+//	account tree nodes [32]byte each, from the root down, excluding the unused node 0
+//	crc32 (Castagnoli) of everything before it, uint32
+//
+// Earners and tokens are written in tree order, so their indices are implied by their position.
+func WriteSnapshot(w io.Writer, d *Distribution, accountTree *merkletree.MerkleTree, snapshotDate time.Time) error {
+	if d.reportingView {
+		return ErrReportingView
+	}
+	if !d.IsMerklized() {
+		return ErrNotMerklized
+	}
+	if len(accountTree.Data) != d.EarnerCount() {
+		return fmt.Errorf("%w: account tree has %d leafs but the distribution has %d earners", ErrInvalidSnapshot, len(accountTree.Data), d.EarnerCount())
+	}
+
+	bw := bufio.NewWriter(w)
+	checksum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	sw := &snapshotWriter{w: io.MultiWriter(bw, checksum)}
+
+	sw.write(snapshotMagic[:])
+	sw.writeUint16(SnapshotVersion)
+	sw.writeUint16(d.LeafScheme().Version())
+	sw.writeUint64(uint64(snapshotDate.Unix()))
+	sw.write(accountTree.Root())
+
+	sw.writeUvarint(uint64(d.EarnerCount()))
+	accountIndex := 0
+	var err error
+	d.store.forEachEarner(func(address gethcommon.Address) bool {
+		earner, tokenRoot, decodeErr := d.LeafScheme().DecodeAccountLeaf(accountTree.Data[accountIndex])
+		if decodeErr != nil {
+			err = fmt.Errorf("%w: %w", ErrInvalidSnapshot, decodeErr)
+			return false
+		}
+		if earner != address || len(tokenRoot) != 32 {
+			err = fmt.Errorf("%w: account tree leaf %d is not for earner %s", ErrInvalidSnapshot, accountIndex, address.Hex())
+			return false
+		}
+		// ReadSnapshot rejects earners without tokens, so they must not be written
+		tokenCount, _ := d.store.tokenCount(address)
+		if tokenCount == 0 {
+			err = fmt.Errorf("%w: earner %s has no tokens", ErrInvalidSnapshot, address.Hex())
+			return false
+		}
+		tokenTree, treeErr := newTokenTree(d.LeafScheme(), d.store, address)
+		if treeErr != nil {
+			err = fmt.Errorf("%w: %w", ErrInvalidSnapshot, treeErr)
+			return false
+		}
+		if !bytes.Equal(tokenTree.Root(), tokenRoot) {
+			err = fmt.Errorf("%w: token root for earner %s does not match its amounts", ErrInvalidSnapshot, address.Hex())
+			return false
+		}
+		sw.write(address.Bytes())
+		sw.write(tokenRoot)
+		sw.writeUvarint(uint64(tokenCount))
+		d.store.forEachToken(address, func(token gethcommon.Address, amount *big.Int) bool {
+			sw.write(token.Bytes())
+			amountBytes := orZero(amount).Bytes()
+			if len(amountBytes) > 32 {
+				err = fmt.Errorf("%w: amount for earner %s and token %s does not fit in 32 bytes", ErrInvalidSnapshot, address.Hex(), token.Hex())
+				return false
+			}
+			sw.write([]byte{byte(len(amountBytes))})
+			sw.write(amountBytes)
+			return true
+		})
+		accountIndex++
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, node := range accountTree.Nodes[1:] {
+		sw.write(node)
+	}
+	if sw.err != nil {
+		return sw.err
+	}
+
+	if err := binary.Write(bw, binary.BigEndian, checksum.Sum32()); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ReadSnapshot reads a merklized distribution written by WriteSnapshot, without recomputing any hashes.
+// The snapshot's leaf scheme must be registered, see RegisterLeafScheme.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	sr := &snapshotReader{
+		r:        bufio.NewReader(r),
+		checksum: crc32.New(crc32.MakeTable(crc32.Castagnoli)),
+	}
+
+	var magic [4]byte
+	sr.read(magic[:])
+	if sr.err == nil && magic != snapshotMagic {
+		return nil, fmt.Errorf("%w: bad magic %x", ErrInvalidSnapshot, magic)
+	}
+	version := sr.readUint16()
+	if sr.err == nil && version != SnapshotVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedSnapshotVersion, version)
+	}
+	schemeVersion := sr.readUint16()
+	var scheme LeafScheme
+	if sr.err == nil {
+		var err error
+		if scheme, err = GetLeafScheme(schemeVersion); err != nil {
+			return nil, err
+		}
+	}
+	snapshotDate := time.Unix(int64(sr.readUint64()), 0).UTC()
+	root := make([]byte, 32)
+	sr.read(root)
+
+	earnerCount := sr.readUvarint()
+	if sr.err != nil {
+		return nil, sr.error()
+	}
+	if earnerCount == 0 || earnerCount > maxLeafs {
+		return nil, fmt.Errorf("%w: earner count %d", ErrInvalidSnapshot, earnerCount)
+	}
+
+	distro := NewDistributionWithLeafScheme(scheme)
+	// counts are not trusted until the checksum is verified, so let slices grow with what is actually read
+	accountLeafs := make([][]byte, 0)
+	for i := uint64(0); i < earnerCount && sr.err == nil; i++ {
+		var earner gethcommon.Address
+		sr.read(earner[:])
+		tokenRoot := make([]byte, 32)
+		sr.read(tokenRoot)
+		accountLeaf, err := scheme.EncodeAccountLeaf(earner, tokenRoot)
+		if err != nil {
+			return nil, err
+		}
+		accountLeafs = append(accountLeafs, accountLeaf)
+
+		tokenCount := sr.readUvarint()
+		if sr.err == nil && (tokenCount == 0 || tokenCount > maxLeafs) {
+			return nil, fmt.Errorf("%w: token count %d for earner %s", ErrInvalidSnapshot, tokenCount, earner.Hex())
+		}
+		for j := uint64(0); j < tokenCount && sr.err == nil; j++ {
+			var token gethcommon.Address
+			sr.read(token[:])
+			amountLen := sr.readByte()
+			if sr.err == nil && amountLen > 32 {
+				return nil, fmt.Errorf("%w: amount length %d", ErrInvalidSnapshot, amountLen)
+			}
+			amountBytes := make([]byte, amountLen)
+			sr.read(amountBytes)
+			if sr.err != nil {
+				break
+			}
+			if err := distro.Set(earner, token, new(big.Int).SetBytes(amountBytes)); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+			}
+		}
+	}
+
+	nodeCount := 2 * nextPowerOfTwo(earnerCount)
+	nodes := [][]byte{nil}
+	for i := uint64(1); i < nodeCount && sr.err == nil; i++ {
+		node := make([]byte, 32)
+		sr.read(node)
+		nodes = append(nodes, node)
+	}
+	if sr.err != nil {
+		return nil, sr.error()
+	}
+
+	// the checksum is not part of what it covers, so read it straight from the underlying reader
+	expected := sr.checksum.Sum32()
+	var actual uint32
+	if err := binary.Read(sr.r, binary.BigEndian, &actual); err != nil {
+		return nil, fmt.Errorf("%w: failed to read checksum: %w", ErrInvalidSnapshot, err)
+	}
+	if actual != expected {
+		return nil, fmt.Errorf("%w: expected %08x, got %08x", ErrSnapshotChecksumMismatch, expected, actual)
+	}
+	if !bytes.Equal(nodes[1], root) {
+		return nil, fmt.Errorf("%w: account tree does not match root", ErrInvalidSnapshot)
+	}
+
+	// the indices are implied by the order, set them once all the data is in
+	distro.setIndices()
+
+	return &Snapshot{
+		SnapshotDate: snapshotDate,
+		Root:         root,
+		Distribution: distro,
+		AccountTree: &merkletree.MerkleTree{
+			Hash:  keccak256.New(),
+			Data:  accountLeafs,
+			Nodes: nodes,
+		},
+	}, nil
+}
+
+type snapshotWriter struct {
+	w   io.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func (s *snapshotWriter) write(p []byte) {
+	if s.err == nil {
+		_, s.err = s.w.Write(p)
+	}
+}
+
+func (s *snapshotWriter) writeUint16(v uint16) {
+	binary.BigEndian.PutUint16(s.buf[:], v)
+	s.write(s.buf[:2])
+}
+
+func (s *snapshotWriter) writeUint64(v uint64) {
+	binary.BigEndian.PutUint64(s.buf[:], v)
+	s.write(s.buf[:8])
+}
+
+func (s *snapshotWriter) writeUvarint(v uint64) {
+	n := binary.PutUvarint(s.buf[:], v)
+	s.write(s.buf[:n])
+}
+
+type snapshotReader struct {
+	r        *bufio.Reader
+	checksum hash.Hash32
+	buf      [8]byte
+	err      error
+}
+
+func (s *snapshotReader) read(p []byte) {
+	if s.err != nil {
+		return
+	}
+	if _, s.err = io.ReadFull(s.r, p); s.err == nil {
+		s.checksum.Write(p)
+	}
+}
+
+func (s *snapshotReader) readByte() byte {
+	s.read(s.buf[:1])
+	return s.buf[0]
+}
+
+func (s *snapshotReader) readUint16() uint16 {
+	s.read(s.buf[:2])
+	return binary.BigEndian.Uint16(s.buf[:2])
+}
+
+func (s *snapshotReader) readUint64() uint64 {
+	s.read(s.buf[:8])
+	return binary.BigEndian.Uint64(s.buf[:8])
+}
+
+func (s *snapshotReader) readUvarint() uint64 {
+	if s.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(checksumByteReader{s})
+	s.err = err
+	return v
+}
+
+// error wraps a read failure, truncation is reported as an invalid snapshot
+func (s *snapshotReader) error() error {
+	if errors.Is(s.err, io.EOF) || errors.Is(s.err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: unexpected end of file", ErrInvalidSnapshot)
+	}
+	return s.err
+}
+
+// checksumByteReader reads single bytes through the snapshotReader so they are included in the checksum
+type checksumByteReader struct {
+	s *snapshotReader
+}
+
+func (c checksumByteReader) ReadByte() (byte, error) {
+	b := c.s.readByte()
+	return b, c.s.err
+}
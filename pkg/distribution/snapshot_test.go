@@ -0,0 +1,141 @@
+package distribution_test
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	d, err := distribution.NewDistributionFromReader(strings.NewReader(getFullTestEarnerLines()))
+	assert.Nil(t, err)
+	accountTree, tokenTrees, err := d.Merklize()
+	assert.Nil(t, err)
+
+	snapshotDate := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	assert.Nil(t, distribution.WriteSnapshot(&buf, d, accountTree, snapshotDate))
+
+	snapshot, err := distribution.ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+
+	assert.Equal(t, snapshotDate, snapshot.SnapshotDate)
+	assert.Equal(t, accountTree.Root(), snapshot.Root)
+	assert.Equal(t, accountTree.Nodes, snapshot.AccountTree.Nodes)
+	assert.Equal(t, accountTree.Data, snapshot.AccountTree.Data)
+
+	for earner, tokenTree := range tokenTrees {
+		expectedIndex, _ := d.GetAccountIndex(earner)
+		index, found := snapshot.Distribution.GetAccountIndex(earner)
+		assert.True(t, found)
+		assert.Equal(t, expectedIndex, index)
+
+		d.ForEachToken(earner, func(token common.Address, expectedAmount *big.Int) bool {
+			amount, found := snapshot.Distribution.Get(earner, token)
+			assert.True(t, found)
+			assert.Equal(t, 0, expectedAmount.Cmp(amount))
+
+			expectedTokenIndex, _ := d.GetTokenIndex(earner, token)
+			tokenIndex, found := snapshot.Distribution.GetTokenIndex(earner, token)
+			assert.True(t, found)
+			assert.Equal(t, expectedTokenIndex, tokenIndex)
+			return true
+		})
+
+		rebuilt, err := snapshot.Distribution.GetTokenTree(earner)
+		assert.Nil(t, err)
+		assert.Equal(t, tokenTree.Root(), rebuilt.Root())
+	}
+}
+
+func TestSnapshotCorruption(t *testing.T) {
+	d := GetTestDistribution()
+	accountTree, _, err := d.Merklize()
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, distribution.WriteSnapshot(&buf, d, accountTree, time.Unix(0, 0)))
+	data := buf.Bytes()
+
+	// flip a bit in the last account tree node
+	corrupted := bytes.Clone(data)
+	corrupted[len(corrupted)-5] ^= 1
+	_, err = distribution.ReadSnapshot(bytes.NewReader(corrupted))
+	assert.ErrorIs(t, err, distribution.ErrSnapshotChecksumMismatch)
+
+	_, err = distribution.ReadSnapshot(bytes.NewReader(data[:len(data)-40]))
+	assert.ErrorIs(t, err, distribution.ErrInvalidSnapshot)
+
+	_, err = distribution.ReadSnapshot(bytes.NewReader([]byte("nope")))
+	assert.ErrorIs(t, err, distribution.ErrInvalidSnapshot)
+
+	badVersion := bytes.Clone(data)
+	badVersion[5] = 0xff
+	_, err = distribution.ReadSnapshot(bytes.NewReader(badVersion))
+	assert.ErrorIs(t, err, distribution.ErrUnsupportedSnapshotVersion)
+}
+
+func TestWriteSnapshotMismatchedTree(t *testing.T) {
+	d := GetTestDistribution()
+	_, tokenTrees, err := d.Merklize()
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	err = distribution.WriteSnapshot(&buf, d, tokenTrees[tests.TestAddresses[0]], time.Unix(0, 0))
+	assert.ErrorIs(t, err, distribution.ErrInvalidSnapshot)
+}
+
+func TestWriteSnapshotStaleTree(t *testing.T) {
+	d := GetTestDistribution()
+	accountTree, _, err := d.Merklize()
+	assert.Nil(t, err)
+
+	// changing an amount after merklizing leaves the tree stale
+	assert.Nil(t, d.Update(tests.TestAddresses[0], tests.TestTokens[1], big.NewInt(100)))
+	var buf bytes.Buffer
+	err = distribution.WriteSnapshot(&buf, d, accountTree, time.Unix(0, 0))
+	assert.ErrorIs(t, err, distribution.ErrNotMerklized)
+
+	// a tree of the same earners with other amounts is caught by its token roots
+	_, _, err = d.Merklize()
+	assert.Nil(t, err)
+	err = distribution.WriteSnapshot(&buf, d, accountTree, time.Unix(0, 0))
+	assert.ErrorIs(t, err, distribution.ErrInvalidSnapshot)
+}
+
+func TestWriteSnapshotEarnerWithoutTokens(t *testing.T) {
+	d := distribution.NewDistribution()
+	assert.Nil(t, d.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(1)))
+	accountTree, err := d.MerklizeAccounts()
+	assert.Nil(t, err)
+
+	// only reachable through the live map of the deprecated GetTokensForEarner
+	tokens, _ := d.GetTokensForEarner(tests.TestAddresses[0])
+	tokens.Delete(tests.TestTokens[0])
+
+	var buf bytes.Buffer
+	err = distribution.WriteSnapshot(&buf, d, accountTree, time.Unix(0, 0))
+	assert.ErrorIs(t, err, distribution.ErrInvalidSnapshot)
+}
+
+func TestReadSnapshotUnregisteredLeafScheme(t *testing.T) {
+	d := GetTestDistribution()
+	accountTree, _, err := d.Merklize()
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, distribution.WriteSnapshot(&buf, d, accountTree, time.Unix(0, 0)))
+	data := buf.Bytes()
+	assert.Equal(t, []byte{0, 1}, data[6:8])
+
+	data[6], data[7] = 0xff, 0xff
+	_, err = distribution.ReadSnapshot(bytes.NewReader(data))
+	assert.ErrorIs(t, err, distribution.ErrUnsupportedLeafScheme)
+}
@@ -0,0 +1,203 @@
+package distribution
+
+import (
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+// leafStore holds the leafs of a Distribution, with earners and each earner's tokens in ascending address order.
+// Amounts passed to fn or returned by get must not be modified.
+type leafStore interface {
+	earnerCount() int
+	leafCount() int
+	// tokenCount returns the number of tokens an earner has and whether the earner is in the store
+	tokenCount(earner gethcommon.Address) (int, bool)
+	get(earner, token gethcommon.Address) (*big.Int, bool)
+	forEachEarner(fn func(earner gethcommon.Address) bool)
+	forEachToken(earner gethcommon.Address, fn func(token gethcommon.Address, amount *big.Int) bool) bool
+	// lastEarner and lastToken return the largest earner and the largest token of an earner, for order checks
+	lastEarner() (gethcommon.Address, bool)
+	lastToken(earner gethcommon.Address) (gethcommon.Address, bool)
+	// appendLeaf adds a leaf whose earner is either in the store or after every other earner,
+	// and whose token is after every other token of the earner
+	appendLeaf(earner, token gethcommon.Address, amount *big.Int) error
+	// setAmount replaces the amount of a leaf that is in the store
+	setAmount(earner, token gethcommon.Address, amount *big.Int) error
+	deleteEarner(earner gethcommon.Address) bool
+	// deleteToken removes a token, and the earner once it has no tokens left
+	deleteToken(earner, token gethcommon.Address) bool
+	// index prepares the lookups of accountIndex and tokenIndex, which are only valid until the next change
+	index()
+	accountIndex(earner gethcommon.Address) (uint64, bool)
+	tokenIndex(earner, token gethcommon.Address) (uint64, bool)
+	// clone deep copies the leafs, empty returns a new store of the same kind
+	clone() leafStore
+	empty() leafStore
+}
+
+// mapStore keeps the leafs in ordered maps, so each leaf can be found and changed in place
+type mapStore struct {
+	data           *orderedmap.OrderedMap[gethcommon.Address, *orderedmap.OrderedMap[gethcommon.Address, *BigInt]]
+	accountIndices map[gethcommon.Address]uint64                        // used for optimizing proving
+	tokenIndices   map[gethcommon.Address]map[gethcommon.Address]uint64 // used for optimizing proving
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{
+		data: orderedmap.New[gethcommon.Address, *orderedmap.OrderedMap[gethcommon.Address, *BigInt]](),
+	}
+}
+
+func (s *mapStore) earnerCount() int {
+	return s.data.Len()
+}
+
+func (s *mapStore) leafCount() int {
+	count := 0
+	for accountPair := s.data.Oldest(); accountPair != nil; accountPair = accountPair.Next() {
+		count += accountPair.Value.Len()
+	}
+	return count
+}
+
+func (s *mapStore) tokenCount(earner gethcommon.Address) (int, bool) {
+	allocatedTokens, found := s.data.Get(earner)
+	if !found {
+		return 0, false
+	}
+	return allocatedTokens.Len(), true
+}
+
+func (s *mapStore) get(earner, token gethcommon.Address) (*big.Int, bool) {
+	allocatedTokens, found := s.data.Get(earner)
+	if !found {
+		return nil, false
+	}
+	amount, found := allocatedTokens.Get(token)
+	if !found {
+		return nil, false
+	}
+	return amount.Int, true
+}
+
+func (s *mapStore) forEachEarner(fn func(earner gethcommon.Address) bool) {
+	for accountPair := s.data.Oldest(); accountPair != nil; accountPair = accountPair.Next() {
+		if !fn(accountPair.Key) {
+			return
+		}
+	}
+}
+
+func (s *mapStore) forEachToken(earner gethcommon.Address, fn func(token gethcommon.Address, amount *big.Int) bool) bool {
+	allocatedTokens, found := s.data.Get(earner)
+	if !found {
+		return false
+	}
+	for tokenPair := allocatedTokens.Oldest(); tokenPair != nil; tokenPair = tokenPair.Next() {
+		if !fn(tokenPair.Key, tokenPair.Value.Int) {
+			break
+		}
+	}
+	return true
+}
+
+func (s *mapStore) lastEarner() (gethcommon.Address, bool) {
+	last := s.data.Newest()
+	if last == nil {
+		return gethcommon.Address{}, false
+	}
+	return last.Key, true
+}
+
+func (s *mapStore) lastToken(earner gethcommon.Address) (gethcommon.Address, bool) {
+	allocatedTokens, found := s.data.Get(earner)
+	if !found || allocatedTokens.Newest() == nil {
+		return gethcommon.Address{}, false
+	}
+	return allocatedTokens.Newest().Key, true
+}
+
+func (s *mapStore) appendLeaf(earner, token gethcommon.Address, amount *big.Int) error {
+	allocatedTokens, found := s.data.Get(earner)
+	if !found {
+		allocatedTokens = orderedmap.New[gethcommon.Address, *BigInt]()
+		s.data.Set(earner, allocatedTokens)
+	}
+	allocatedTokens.Set(token, &BigInt{Int: amount})
+	return nil
+}
+
+func (s *mapStore) setAmount(earner, token gethcommon.Address, amount *big.Int) error {
+	allocatedTokens, _ := s.data.Get(earner)
+	existing, _ := allocatedTokens.Get(token)
+	existing.Int = amount
+	return nil
+}
+
+func (s *mapStore) deleteEarner(earner gethcommon.Address) bool {
+	_, found := s.data.Delete(earner)
+	return found
+}
+
+func (s *mapStore) deleteToken(earner, token gethcommon.Address) bool {
+	allocatedTokens, found := s.data.Get(earner)
+	if !found {
+		return false
+	}
+	if _, found := allocatedTokens.Delete(token); !found {
+		return false
+	}
+	if allocatedTokens.Len() == 0 {
+		s.data.Delete(earner)
+	}
+	return true
+}
+
+func (s *mapStore) index() {
+	s.accountIndices = make(map[gethcommon.Address]uint64, s.data.Len())
+	s.tokenIndices = make(map[gethcommon.Address]map[gethcommon.Address]uint64, s.data.Len())
+	accountIndex := uint64(0)
+	for accountPair := s.data.Oldest(); accountPair != nil; accountPair = accountPair.Next() {
+		s.accountIndices[accountPair.Key] = accountIndex
+		indices := make(map[gethcommon.Address]uint64, accountPair.Value.Len())
+		tokenIndex := uint64(0)
+		for tokenPair := accountPair.Value.Oldest(); tokenPair != nil; tokenPair = tokenPair.Next() {
+			indices[tokenPair.Key] = tokenIndex
+			tokenIndex++
+		}
+		s.tokenIndices[accountPair.Key] = indices
+		accountIndex++
+	}
+}
+
+func (s *mapStore) accountIndex(earner gethcommon.Address) (uint64, bool) {
+	index, found := s.accountIndices[earner]
+	return index, found
+}
+
+func (s *mapStore) tokenIndex(earner, token gethcommon.Address) (uint64, bool) {
+	indices, found := s.tokenIndices[earner]
+	if !found {
+		return 0, false
+	}
+	index, found := indices[token]
+	return index, found
+}
+
+func (s *mapStore) clone() leafStore {
+	cloned := newMapStore()
+	for accountPair := s.data.Oldest(); accountPair != nil; accountPair = accountPair.Next() {
+		tokens := orderedmap.New[gethcommon.Address, *BigInt](accountPair.Value.Len())
+		for tokenPair := accountPair.Value.Oldest(); tokenPair != nil; tokenPair = tokenPair.Next() {
+			tokens.Set(tokenPair.Key, &BigInt{Int: copyBigInt(tokenPair.Value.Int)})
+		}
+		cloned.data.Set(accountPair.Key, tokens)
+	}
+	return cloned
+}
+
+func (s *mapStore) empty() leafStore {
+	return newMapStore()
+}
@@ -0,0 +1,153 @@
+package distribution
+
+import (
+	"bytes"
+	"container/heap"
+	"math/big"
+	"math/bits"
+	"sort"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// EarnerAmount is an earner and its cumulative amount of a token
+type EarnerAmount struct {
+	Earner gethcommon.Address `json:"earner"`
+	Amount *big.Int           `json:"amount"`
+}
+
+// TokenSummary aggregates every earner's cumulative amount of a single token
+type TokenSummary struct {
+	Token       gethcommon.Address `json:"token"`
+	Total       *big.Int           `json:"total"`
+	EarnerCount int                `json:"earner_count"`
+	MinAmount   *big.Int           `json:"min_amount"`
+	MaxAmount   *big.Int           `json:"max_amount"`
+	// TopEarners are the earners with the largest amounts, largest first
+	TopEarners []EarnerAmount `json:"top_earners"`
+}
+
+// Summary describes the size and contents of a distribution
+type Summary struct {
+	EarnerCount int `json:"earner_count"`
+	TokenCount  int `json:"token_count"`
+	LeafCount   int `json:"leaf_count"`
+	// depths are the number of hashes in a proof
+	AccountTreeDepth  int `json:"account_tree_depth"`
+	MaxTokenTreeDepth int `json:"max_token_tree_depth"`
+	// Tokens are in token address order
+	Tokens []*TokenSummary `json:"tokens"`
+}
+
+// Summarize computes summary statistics for the distribution in a single pass,
+// keeping the topEarners largest earners of each token.
+func (d *Distribution) Summarize(topEarners int) *Summary {
+	summary := &Summary{
+		EarnerCount:      d.EarnerCount(),
+		AccountTreeDepth: treeDepth(d.EarnerCount()),
+		Tokens:           make([]*TokenSummary, 0),
+	}
+
+	tokens := make(map[gethcommon.Address]*TokenSummary)
+	tops := make(map[gethcommon.Address]*earnerAmountHeap)
+	d.store.forEachEarner(func(earner gethcommon.Address) bool {
+		tokenCount, _ := d.store.tokenCount(earner)
+		summary.LeafCount += tokenCount
+		if depth := treeDepth(tokenCount); depth > summary.MaxTokenTreeDepth {
+			summary.MaxTokenTreeDepth = depth
+		}
+
+		d.store.forEachToken(earner, func(token gethcommon.Address, amount *big.Int) bool {
+			amount = orZero(amount)
+			tokenSummary, found := tokens[token]
+			if !found {
+				tokenSummary = &TokenSummary{
+					Token:     token,
+					Total:     new(big.Int),
+					MinAmount: new(big.Int).Set(amount),
+					MaxAmount: new(big.Int).Set(amount),
+				}
+				tokens[token] = tokenSummary
+				tops[token] = &earnerAmountHeap{}
+			}
+
+			tokenSummary.Total.Add(tokenSummary.Total, amount)
+			tokenSummary.EarnerCount++
+			if amount.Cmp(tokenSummary.MinAmount) < 0 {
+				tokenSummary.MinAmount.Set(amount)
+			}
+			if amount.Cmp(tokenSummary.MaxAmount) > 0 {
+				tokenSummary.MaxAmount.Set(amount)
+			}
+			tops[token].push(EarnerAmount{Earner: earner, Amount: amount}, topEarners)
+			return true
+		})
+		return true
+	})
+
+	for token, tokenSummary := range tokens {
+		tokenSummary.TopEarners = tops[token].sorted()
+		summary.Tokens = append(summary.Tokens, tokenSummary)
+	}
+	sort.Slice(summary.Tokens, func(i, j int) bool {
+		return summary.Tokens[i].Token.Cmp(summary.Tokens[j].Token) < 0
+	})
+	summary.TokenCount = len(summary.Tokens)
+
+	return summary
+}
+
+// treeDepth returns the number of hashes in a proof for a tree with n leafs
+func treeDepth(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return bits.Len(uint(n - 1))
+}
+
+// earnerAmountHeap is a min heap of the largest earners seen so far
+type earnerAmountHeap []EarnerAmount
+
+// less orders by amount, then by address descending so that smaller addresses win ties
+func earnerAmountLess(a, b EarnerAmount) bool {
+	if c := a.Amount.Cmp(b.Amount); c != 0 {
+		return c < 0
+	}
+	return bytes.Compare(a.Earner[:], b.Earner[:]) > 0
+}
+
+func (h earnerAmountHeap) Len() int           { return len(h) }
+func (h earnerAmountHeap) Less(i, j int) bool { return earnerAmountLess(h[i], h[j]) }
+func (h earnerAmountHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *earnerAmountHeap) Push(x any)        { *h = append(*h, x.(EarnerAmount)) }
+func (h *earnerAmountHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// push adds e, keeping at most limit entries
+func (h *earnerAmountHeap) push(e EarnerAmount, limit int) {
+	if limit <= 0 {
+		return
+	}
+	if h.Len() < limit {
+		heap.Push(h, EarnerAmount{Earner: e.Earner, Amount: new(big.Int).Set(e.Amount)})
+		return
+	}
+	if earnerAmountLess((*h)[0], e) {
+		(*h)[0] = EarnerAmount{Earner: e.Earner, Amount: new(big.Int).Set(e.Amount)}
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted returns the entries largest first
+func (h *earnerAmountHeap) sorted() []EarnerAmount {
+	result := make([]EarnerAmount, len(*h))
+	copy(result, *h)
+	sort.Slice(result, func(i, j int) bool {
+		return earnerAmountLess(result[j], result[i])
+	})
+	return result
+}
@@ -0,0 +1,52 @@
+package distribution_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	summary := GetTestDistribution().Summarize(2)
+
+	assert.Equal(t, len(tests.TestAddresses), summary.EarnerCount)
+	assert.Equal(t, len(tests.TestTokens), summary.TokenCount)
+	assert.Equal(t, 15, summary.LeafCount)
+	assert.Equal(t, 3, summary.AccountTreeDepth)
+	assert.Equal(t, 3, summary.MaxTokenTreeDepth)
+	assert.Len(t, summary.Tokens, len(tests.TestTokens))
+
+	first := summary.Tokens[0]
+	assert.Equal(t, tests.TestTokens[0], first.Token)
+	assert.Equal(t, big.NewInt(15), first.Total)
+	assert.Equal(t, 5, first.EarnerCount)
+	assert.Equal(t, big.NewInt(1), first.MinAmount)
+	assert.Equal(t, big.NewInt(5), first.MaxAmount)
+	assert.Equal(t, []distribution.EarnerAmount{
+		{Earner: tests.TestAddresses[4], Amount: big.NewInt(5)},
+		{Earner: tests.TestAddresses[3], Amount: big.NewInt(4)},
+	}, first.TopEarners)
+
+	last := summary.Tokens[4]
+	assert.Equal(t, tests.TestTokens[4], last.Token)
+	assert.Equal(t, big.NewInt(5), last.Total)
+	assert.Equal(t, 1, last.EarnerCount)
+	assert.Equal(t, []distribution.EarnerAmount{
+		{Earner: tests.TestAddresses[0], Amount: big.NewInt(5)},
+	}, last.TopEarners)
+
+	_, err := json.Marshal(summary)
+	assert.Nil(t, err)
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	summary := distribution.NewDistribution().Summarize(10)
+
+	assert.Equal(t, 0, summary.EarnerCount)
+	assert.Equal(t, 0, summary.LeafCount)
+	assert.Empty(t, summary.Tokens)
+}
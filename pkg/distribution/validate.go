@@ -0,0 +1,104 @@
+package distribution
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+var ErrNotMonotonic = errors.New("cumulative amounts are not monotonic")
+
+// ViolationKind is the way in which a cumulative amount went backwards between two distributions
+type ViolationKind string
+
+const (
+	ViolationAmountDecreased ViolationKind = "amount_decreased"
+	ViolationTokenRemoved    ViolationKind = "token_removed"
+	ViolationEarnerRemoved   ViolationKind = "earner_removed"
+)
+
+// Violation is an earner and token whose cumulative amount decreased or disappeared.
+// Next is nil when the token or earner was removed.
+type Violation struct {
+	Kind     ViolationKind      `json:"kind"`
+	Earner   gethcommon.Address `json:"earner"`
+	Token    gethcommon.Address `json:"token"`
+	Previous *big.Int           `json:"previous"`
+	Next     *big.Int           `json:"next"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: earner %s token %s previous %s next %v", v.Kind, v.Earner.Hex(), v.Token.Hex(), v.Previous, v.Next)
+}
+
+// MonotonicityError lists every violation found by ValidateMonotonicity
+type MonotonicityError struct {
+	Violations []Violation
+}
+
+func (e *MonotonicityError) Error() string {
+	return fmt.Sprintf("%v: %d violations, first: %s", ErrNotMonotonic, len(e.Violations), e.Violations[0])
+}
+
+func (e *MonotonicityError) Unwrap() error {
+	return ErrNotMonotonic
+}
+
+// FindMonotonicityViolations returns every earner and token whose cumulative amount in next is lower than in prev,
+// or which is in prev but not in next. Removed earners are reported once per token.
+// Both distributions must be in address order, as Set enforces.
+func FindMonotonicityViolations(prev, next *Distribution) []Violation {
+	violations := make([]Violation, 0)
+
+	mergeSorted(prev.earners(), next.earners(), identity, func(earner gethcommon.Address, inPrev, inNext *gethcommon.Address) {
+		if inPrev == nil {
+			return
+		}
+		if inNext == nil {
+			for _, tokenAmount := range prev.tokenAmounts(earner) {
+				violations = append(violations, Violation{
+					Kind:     ViolationEarnerRemoved,
+					Earner:   earner,
+					Token:    tokenAmount.Token,
+					Previous: tokenAmount.Amount,
+				})
+			}
+			return
+		}
+		mergeSorted(prev.tokenAmounts(earner), next.tokenAmounts(earner), tokenOf, func(token gethcommon.Address, prevAmount, nextAmount *TokenAmount) {
+			switch {
+			case prevAmount == nil:
+				return
+			case nextAmount == nil:
+				violations = append(violations, Violation{
+					Kind:     ViolationTokenRemoved,
+					Earner:   earner,
+					Token:    token,
+					Previous: prevAmount.Amount,
+				})
+			case nextAmount.Amount.Cmp(prevAmount.Amount) < 0:
+				violations = append(violations, Violation{
+					Kind:     ViolationAmountDecreased,
+					Earner:   earner,
+					Token:    token,
+					Previous: prevAmount.Amount,
+					Next:     nextAmount.Amount,
+				})
+			}
+		})
+	})
+
+	return violations
+}
+
+// ValidateMonotonicity returns a *MonotonicityError if any cumulative amount in prev decreased or disappeared in next.
+// Submitting a root for next when this fails would make claims revert or strand funds.
+func ValidateMonotonicity(prev, next *Distribution) error {
+	violations := FindMonotonicityViolations(prev, next)
+	if len(violations) > 0 {
+		return &MonotonicityError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package distribution_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/internal/tests"
+	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/distribution"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMonotonicity(t *testing.T) {
+	// every amount in the complete distribution is one more than in the test distribution
+	assert.Nil(t, distribution.ValidateMonotonicity(GetTestDistribution(), GetCompleteTestDistribution()))
+	assert.Nil(t, distribution.ValidateMonotonicity(GetTestDistribution(), GetTestDistribution()))
+}
+
+func TestValidateMonotonicityViolations(t *testing.T) {
+	prev := distribution.NewDistribution()
+	assert.Nil(t, prev.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(10)))
+	assert.Nil(t, prev.Set(tests.TestAddresses[0], tests.TestTokens[1], big.NewInt(10)))
+	assert.Nil(t, prev.Set(tests.TestAddresses[1], tests.TestTokens[0], big.NewInt(10)))
+	assert.Nil(t, prev.Set(tests.TestAddresses[1], tests.TestTokens[1], big.NewInt(10)))
+
+	next := distribution.NewDistribution()
+	assert.Nil(t, next.Set(tests.TestAddresses[0], tests.TestTokens[0], big.NewInt(9)))
+	assert.Nil(t, next.Set(tests.TestAddresses[0], tests.TestTokens[2], big.NewInt(1)))
+	assert.Nil(t, next.Set(tests.TestAddresses[2], tests.TestTokens[0], big.NewInt(1)))
+
+	err := distribution.ValidateMonotonicity(prev, next)
+	assert.ErrorIs(t, err, distribution.ErrNotMonotonic)
+
+	var monotonicityErr *distribution.MonotonicityError
+	assert.ErrorAs(t, err, &monotonicityErr)
+	assert.Equal(t, []distribution.Violation{
+		{
+			Kind:     distribution.ViolationAmountDecreased,
+			Earner:   tests.TestAddresses[0],
+			Token:    tests.TestTokens[0],
+			Previous: big.NewInt(10),
+			Next:     big.NewInt(9),
+		},
+		{
+			Kind:     distribution.ViolationTokenRemoved,
+			Earner:   tests.TestAddresses[0],
+			Token:    tests.TestTokens[1],
+			Previous: big.NewInt(10),
+		},
+		{
+			Kind:     distribution.ViolationEarnerRemoved,
+			Earner:   tests.TestAddresses[1],
+			Token:    tests.TestTokens[0],
+			Previous: big.NewInt(10),
+		},
+		{
+			Kind:     distribution.ViolationEarnerRemoved,
+			Earner:   tests.TestAddresses[1],
+			Token:    tests.TestTokens[1],
+			Previous: big.NewInt(10),
+		},
+	}, monotonicityErr.Violations)
+}
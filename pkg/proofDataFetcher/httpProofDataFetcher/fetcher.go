@@ -1,6 +1,7 @@
 package httpProofDataFetcher
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -9,7 +10,6 @@ import (
 	"github.com/Layr-Labs/eigenlayer-rewards-proofs/pkg/utils"
 	"io"
 	"net/http"
-	"strings"
 )
 
 type HttpProofDataFetcher struct {
@@ -37,30 +37,23 @@ func (h *HttpProofDataFetcher) FetchClaimAmountsForDate(ctx context.Context, dat
 
 	fullUrl := h.buildClaimAmountsUrl(date)
 
-	rawBody, err := h.handleRequest(ctx, fullUrl)
+	res, err := h.doRequest(ctx, fullUrl)
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
 
-	return h.ProcessClaimAmountsFromRawBody(ctx, rawBody)
+	return h.ProcessClaimAmountsFromReader(ctx, res.Body)
 }
 
 func (h *HttpProofDataFetcher) ProcessClaimAmountsFromRawBody(ctx context.Context, rawBody []byte) (*proofDataFetcher.RewardProofData, error) {
-	strLines := strings.Split(string(rawBody), "\n")
-	distro := distribution.NewDistribution()
-	lines := []*distribution.EarnerLine{}
-	for _, line := range strLines {
-		if line == "" {
-			continue
-		}
-		earner := &distribution.EarnerLine{}
-		if err := json.Unmarshal([]byte(line), earner); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal line: %s - %w", line, err)
-		}
-		lines = append(lines, earner)
-	}
-
-	if err := distro.LoadLines(lines); err != nil {
+	return h.ProcessClaimAmountsFromReader(ctx, bytes.NewReader(rawBody))
+}
+
+// ProcessClaimAmountsFromReader streams newline delimited claim amounts into a merklized distribution
+func (h *HttpProofDataFetcher) ProcessClaimAmountsFromReader(ctx context.Context, r io.Reader) (*proofDataFetcher.RewardProofData, error) {
+	distro, err := distribution.NewDistributionFromReader(r)
+	if err != nil {
 		return nil, fmt.Errorf("failed to load lines: %w", err)
 	}
 
@@ -141,6 +134,23 @@ func (h *HttpProofDataFetcher) FetchDisabledRoots(ctx context.Context) ([]*proof
 }
 
 func (h *HttpProofDataFetcher) handleRequest(ctx context.Context, fullUrl string) ([]byte, error) {
+	res, err := h.doRequest(ctx, fullUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	rawBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return rawBody, nil
+}
+
+// doRequest performs the request and returns the response with its body unread.
+// The caller is responsible for closing the body.
+func (h *HttpProofDataFetcher) doRequest(ctx context.Context, fullUrl string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullUrl, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to form request: %w", err)
@@ -150,18 +160,13 @@ func (h *HttpProofDataFetcher) handleRequest(ctx context.Context, fullUrl string
 	if err != nil {
 		return nil, fmt.Errorf("request failed %w", err)
 	}
-	defer res.Body.Close()
-
-	rawBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
 
 	if res.StatusCode >= 400 {
+		res.Body.Close()
 		return nil, fmt.Errorf("Received error code '%d'", res.StatusCode)
 	}
 
-	return rawBody, nil
+	return res, nil
 }
 
 func (h *HttpProofDataFetcher) buildRecentSnapshotsUrl() string {